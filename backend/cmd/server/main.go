@@ -50,9 +50,21 @@ func main() {
 
 	repos := repository.NewRepository(db)
 	infoClient := service.NewMusicInfoClient(musicInfoAPI)
-	services := service.NewService(repos, infoClient)
+	services := service.NewService(repos, infoClient, service.Config{
+		FuzzyThreshold:   viper.GetFloat64("search.fuzzyThreshold"),
+		IdempotencyTTL:   viper.GetDuration("idempotency.ttl"),
+		IdempotencyLease: viper.GetDuration("idempotency.lease"),
+		TrashRetention:   viper.GetDuration("trash.retention"),
+		BatchSize:        viper.GetInt("batch.size"),
+		BatchConcurrency: viper.GetInt("batch.concurrency"),
+	})
 	handlers := handler.NewHandler(services)
 
+	purgeCtx, stopPurge := context.WithCancel(context.Background())
+	go services.SongService.RunTrashPurge(purgeCtx, viper.GetDuration("trash.purgeInterval"))
+	go services.SuggestService.RunSuggestionRefresh(purgeCtx, viper.GetDuration("suggest.refreshInterval"))
+	go services.IdempotencyService.RunIdempotencyKeyPurge(purgeCtx, viper.GetDuration("idempotency.purgeInterval"))
+
 	srv := new(models.Server)
 	go func() {
 		if err := srv.Run(viper.GetString("port"), handlers.InitRoutes()); err != nil {
@@ -68,6 +80,8 @@ func main() {
 
 	logrus.Print("music-library-app Shutting Down")
 
+	stopPurge()
+
 	if err := srv.Shutdown(context.Background()); err != nil {
 		logrus.Errorf("error occured on server shutting down: %s", err.Error())
 	}
@@ -0,0 +1,279 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/AntonZatsepilin/music-library.git/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// CreateAlbum godoc
+// @Summary Create new album
+// @Description Create new album; the artist is resolved by name or created
+// @Tags albums
+// @Accept json
+// @Produce json
+// @Param input body models.CreateAlbumRequest true "Album data"
+// @Success 201 {object} models.Album
+// @Failure 400 {object} errorResponse
+// @Failure 422 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /albums [post]
+func (h *Handler) CreateAlbum(c *gin.Context) {
+	logrus.Debug("Received a request to create an album")
+
+	var input models.CreateAlbumRequest
+
+	if err := c.BindJSON(&input); err != nil {
+		logrus.WithError(err).Warn("Invalid request format")
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	album, err := h.services.AlbumService.CreateAlbum(input)
+	if err != nil {
+		serviceErrorResponse(c, err, "Album creation error")
+		return
+	}
+
+	logrus.Info("Album created successfully")
+	c.JSON(http.StatusCreated, album)
+}
+
+// GetAlbums godoc
+// @Summary Get albums list
+// @Description Get filtered and paginated list of albums
+// @Tags albums
+// @Produce json
+// @Param artistId query int false "Filter by artist ID"
+// @Param title query string false "Search in album titles"
+// @Param page query int false "Page number" default(1) minimum(1)
+// @Param limit query int false "Items per page" default(10) minimum(1) maximum(100)
+// @Success 200 {object} models.AlbumsResponse
+// @Failure 400 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /albums [get]
+func (h *Handler) GetAlbums(c *gin.Context) {
+	var filter models.AlbumFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid filter parameters")
+		return
+	}
+
+	page, limit, err := getPagination(c)
+	if err != nil {
+		return
+	}
+
+	albums, total, err := h.services.AlbumService.GetAlbums(filter, page, limit)
+	if err != nil {
+		serviceErrorResponse(c, err, "Albums retrieval error")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.AlbumsResponse{
+		Data:  albums,
+		Total: total,
+		Page:  page,
+		Limit: limit,
+	})
+}
+
+// GetAlbumById godoc
+// @Summary Get album by ID
+// @Description Get album details by ID
+// @Tags albums
+// @Produce json
+// @Param id path int true "Album ID"
+// @Success 200 {object} models.Album
+// @Failure 400 {object} errorResponse
+// @Failure 404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /albums/{id} [get]
+func (h *Handler) GetAlbumById(c *gin.Context) {
+	albumId, err := getAlbumId(c)
+	if err != nil {
+		return
+	}
+
+	album, err := h.services.AlbumService.GetAlbumById(albumId)
+	if err != nil {
+		serviceErrorResponse(c, err, "Album retrieval error")
+		return
+	}
+
+	c.JSON(http.StatusOK, album)
+}
+
+// UpdateAlbumById godoc
+// @Summary Update album
+// @Description Update album details
+// @Tags albums
+// @Accept json
+// @Produce json
+// @Param id path int true "Album ID"
+// @Param input body models.UpdateAlbumRequest true "Update data"
+// @Success 200 {object} statusResponse
+// @Failure 400 {object} errorResponse
+// @Failure 404 {object} errorResponse
+// @Failure 409 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /albums/{id} [put]
+func (h *Handler) UpdateAlbumById(c *gin.Context) {
+	logrus.Debug("Received a request to update an album")
+
+	albumId, err := getAlbumId(c)
+	if err != nil {
+		return
+	}
+
+	var input models.UpdateAlbumRequest
+
+	if err := c.BindJSON(&input); err != nil {
+		logrus.WithError(err).Warn("Invalid request format")
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := h.services.AlbumService.UpdateAlbumById(albumId, input); err != nil {
+		serviceErrorResponse(c, err, "Album update error")
+		return
+	}
+
+	logrus.Info("Album updated successfully")
+	c.JSON(http.StatusOK, statusResponse{"Album updated successfully"})
+}
+
+// DeleteAlbumById godoc
+// @Summary Delete album
+// @Description Delete album by ID; its songs are kept and detached from the album
+// @Tags albums
+// @Produce json
+// @Param id path int true "Album ID"
+// @Success 200 {object} statusResponse
+// @Failure 400 {object} errorResponse
+// @Failure 404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /albums/{id} [delete]
+func (h *Handler) DeleteAlbumById(c *gin.Context) {
+	logrus.Debug("Received a request to delete an album")
+
+	albumId, err := getAlbumId(c)
+	if err != nil {
+		return
+	}
+
+	if err := h.services.AlbumService.DeleteAlbumById(albumId, getAudit(c)); err != nil {
+		serviceErrorResponse(c, err, "Album deletion error")
+		return
+	}
+
+	logrus.Info("Album deleted successfully")
+	c.JSON(http.StatusOK, statusResponse{"Album deleted successfully"})
+}
+
+// GetAlbumTracks godoc
+// @Summary Get album tracks
+// @Description Get songs of an album ordered by disc and track number
+// @Tags albums
+// @Produce json
+// @Param id path int true "Album ID"
+// @Success 200 {array} models.Song
+// @Failure 400 {object} errorResponse
+// @Failure 404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /albums/{id}/tracks [get]
+func (h *Handler) GetAlbumTracks(c *gin.Context) {
+	albumId, err := getAlbumId(c)
+	if err != nil {
+		return
+	}
+
+	tracks, err := h.services.AlbumService.GetAlbumTracks(albumId)
+	if err != nil {
+		serviceErrorResponse(c, err, "Album tracks retrieval error")
+		return
+	}
+
+	c.JSON(http.StatusOK, tracks)
+}
+
+// SetAlbumTrack godoc
+// @Summary Place song on album
+// @Description Put a song on an album at the given disc and track position
+// @Tags albums
+// @Accept json
+// @Produce json
+// @Param id path int true "Album ID"
+// @Param songId path int true "Song ID"
+// @Param input body models.AlbumTrackRequest true "Track position"
+// @Success 200 {object} statusResponse
+// @Failure 400 {object} errorResponse
+// @Failure 404 {object} errorResponse
+// @Failure 409 {object} errorResponse
+// @Failure 422 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /albums/{id}/tracks/{songId} [put]
+func (h *Handler) SetAlbumTrack(c *gin.Context) {
+	albumId, err := getAlbumId(c)
+	if err != nil {
+		return
+	}
+
+	songId, err := getIdParam(c, "songId", "song")
+	if err != nil {
+		return
+	}
+
+	var input models.AlbumTrackRequest
+
+	if err := c.BindJSON(&input); err != nil {
+		logrus.WithError(err).Warn("Invalid request format")
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if input.Disc < 0 || input.Track < 1 {
+		newErrorResponse(c, http.StatusBadRequest, "disc and track numbers must be positive")
+		return
+	}
+
+	if err := h.services.AlbumService.SetAlbumTrack(albumId, songId, input, getAudit(c)); err != nil {
+		serviceErrorResponse(c, err, "Album track update error")
+		return
+	}
+
+	c.JSON(http.StatusOK, statusResponse{"Song placed on album successfully"})
+}
+
+// RemoveAlbumTrack godoc
+// @Summary Remove song from album
+// @Description Detach a song from an album; the song itself is kept
+// @Tags albums
+// @Produce json
+// @Param id path int true "Album ID"
+// @Param songId path int true "Song ID"
+// @Success 200 {object} statusResponse
+// @Failure 400 {object} errorResponse
+// @Failure 404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /albums/{id}/tracks/{songId} [delete]
+func (h *Handler) RemoveAlbumTrack(c *gin.Context) {
+	albumId, err := getAlbumId(c)
+	if err != nil {
+		return
+	}
+
+	songId, err := getIdParam(c, "songId", "song")
+	if err != nil {
+		return
+	}
+
+	if err := h.services.AlbumService.RemoveAlbumTrack(albumId, songId, getAudit(c)); err != nil {
+		serviceErrorResponse(c, err, "Album track removal error")
+		return
+	}
+
+	c.JSON(http.StatusOK, statusResponse{"Song removed from album successfully"})
+}
@@ -0,0 +1,207 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/AntonZatsepilin/music-library.git/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// CreateArtist godoc
+// @Summary Create new artist
+// @Description Create new artist (group) with metadata
+// @Tags artists
+// @Accept json
+// @Produce json
+// @Param input body models.CreateArtistRequest true "Artist data"
+// @Success 201 {object} models.Artist
+// @Failure 400 {object} errorResponse
+// @Failure 409 {object} errorResponse
+// @Failure 422 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /artists [post]
+func (h *Handler) CreateArtist(c *gin.Context) {
+	logrus.Debug("Received a request to create an artist")
+
+	var input models.CreateArtistRequest
+
+	if err := c.BindJSON(&input); err != nil {
+		logrus.WithError(err).Warn("Invalid request format")
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	artist, err := h.services.ArtistService.CreateArtist(input)
+	if err != nil {
+		serviceErrorResponse(c, err, "Artist creation error")
+		return
+	}
+
+	logrus.Info("Artist created successfully")
+	c.JSON(http.StatusCreated, artist)
+}
+
+// GetArtists godoc
+// @Summary Get artists list
+// @Description Get paginated list of artists, optionally filtered by name or alias
+// @Tags artists
+// @Produce json
+// @Param name query string false "Search in artist names and aliases"
+// @Param page query int false "Page number" default(1) minimum(1)
+// @Param limit query int false "Items per page" default(10) minimum(1) maximum(100)
+// @Success 200 {object} models.ArtistsResponse
+// @Failure 400 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /artists [get]
+func (h *Handler) GetArtists(c *gin.Context) {
+	page, limit, err := getPagination(c)
+	if err != nil {
+		return
+	}
+
+	artists, total, err := h.services.ArtistService.GetArtists(c.Query("name"), page, limit)
+	if err != nil {
+		serviceErrorResponse(c, err, "Artists retrieval error")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ArtistsResponse{
+		Data:  artists,
+		Total: total,
+		Page:  page,
+		Limit: limit,
+	})
+}
+
+// GetArtistById godoc
+// @Summary Get artist by ID
+// @Description Get artist details by ID
+// @Tags artists
+// @Produce json
+// @Param id path int true "Artist ID"
+// @Success 200 {object} models.Artist
+// @Failure 400 {object} errorResponse
+// @Failure 404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /artists/{id} [get]
+func (h *Handler) GetArtistById(c *gin.Context) {
+	artistId, err := getArtistId(c)
+	if err != nil {
+		return
+	}
+
+	artist, err := h.services.ArtistService.GetArtistById(artistId)
+	if err != nil {
+		serviceErrorResponse(c, err, "Artist retrieval error")
+		return
+	}
+
+	c.JSON(http.StatusOK, artist)
+}
+
+// UpdateArtistById godoc
+// @Summary Update artist
+// @Description Update artist details; renaming an artist renames the group of all its songs
+// @Tags artists
+// @Accept json
+// @Produce json
+// @Param id path int true "Artist ID"
+// @Param input body models.UpdateArtistRequest true "Update data"
+// @Success 200 {object} statusResponse
+// @Failure 400 {object} errorResponse
+// @Failure 404 {object} errorResponse
+// @Failure 409 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /artists/{id} [put]
+func (h *Handler) UpdateArtistById(c *gin.Context) {
+	logrus.Debug("Received a request to update an artist")
+
+	artistId, err := getArtistId(c)
+	if err != nil {
+		return
+	}
+
+	var input models.UpdateArtistRequest
+
+	if err := c.BindJSON(&input); err != nil {
+		logrus.WithError(err).Warn("Invalid request format")
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := h.services.ArtistService.UpdateArtistById(artistId, input); err != nil {
+		serviceErrorResponse(c, err, "Artist update error")
+		return
+	}
+
+	logrus.Info("Artist updated successfully")
+	c.JSON(http.StatusOK, statusResponse{"Artist updated successfully"})
+}
+
+// DeleteArtistById godoc
+// @Summary Delete artist
+// @Description Delete artist by ID; artists that still have songs cannot be deleted
+// @Tags artists
+// @Produce json
+// @Param id path int true "Artist ID"
+// @Success 200 {object} statusResponse
+// @Failure 400 {object} errorResponse
+// @Failure 404 {object} errorResponse
+// @Failure 409 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /artists/{id} [delete]
+func (h *Handler) DeleteArtistById(c *gin.Context) {
+	logrus.Debug("Received a request to delete an artist")
+
+	artistId, err := getArtistId(c)
+	if err != nil {
+		return
+	}
+
+	if err := h.services.ArtistService.DeleteArtistById(artistId); err != nil {
+		serviceErrorResponse(c, err, "Artist deletion error")
+		return
+	}
+
+	logrus.Info("Artist deleted successfully")
+	c.JSON(http.StatusOK, statusResponse{"Artist deleted successfully"})
+}
+
+// GetArtistSongs godoc
+// @Summary Get artist songs
+// @Description Get paginated list of songs of an artist
+// @Tags artists
+// @Produce json
+// @Param id path int true "Artist ID"
+// @Param page query int false "Page number" default(1) minimum(1)
+// @Param limit query int false "Items per page" default(10) minimum(1) maximum(100)
+// @Success 200 {object} models.SongsResponse
+// @Failure 400 {object} errorResponse
+// @Failure 404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /artists/{id}/songs [get]
+func (h *Handler) GetArtistSongs(c *gin.Context) {
+	artistId, err := getArtistId(c)
+	if err != nil {
+		return
+	}
+
+	page, limit, err := getPagination(c)
+	if err != nil {
+		return
+	}
+
+	songs, total, err := h.services.ArtistService.GetArtistSongs(artistId, page, limit)
+	if err != nil {
+		serviceErrorResponse(c, err, "Artist songs retrieval error")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SongsResponse{
+		Data:  songs,
+		Total: &total,
+		Page:  page,
+		Limit: limit,
+	})
+}
@@ -0,0 +1,323 @@
+package handler
+
+import (
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AntonZatsepilin/music-library.git/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// exportWriteTimeout replaces the server timeouts for exports. It is set
+	// before the first songs are fetched and renewed whenever a part of the
+	// export is sent, so an export may run as long as it makes progress.
+	exportWriteTimeout = time.Minute
+	// exportFlushEvery is how many songs are sent at a time.
+	exportFlushEvery = 500
+	// exportListSeparator joins the genres and tags of a song in a CSV field.
+	exportListSeparator = "|"
+)
+
+// songEncoder writes songs in an export format. title names the exported
+// list in formats that carry one.
+type songEncoder interface {
+	begin(w io.Writer, title string) error
+	encode(song models.Song) error
+	flush() error
+	end() error
+}
+
+type exportFormat struct {
+	extension   string
+	contentType string
+	newEncoder  func() songEncoder
+}
+
+var exportFormats = map[string]exportFormat{
+	"csv":    {"csv", "text/csv; charset=utf-8", func() songEncoder { return &csvSongEncoder{} }},
+	"ndjson": {"ndjson", "application/x-ndjson", func() songEncoder { return &ndjsonSongEncoder{} }},
+	"json":   {"json", "application/json; charset=utf-8", func() songEncoder { return &jsonSongEncoder{} }},
+	"m3u8":   {"m3u8", "audio/x-mpegurl", func() songEncoder { return &m3u8SongEncoder{} }},
+	"xspf":   {"xspf", "application/xspf+xml", func() songEncoder { return &xspfSongEncoder{} }},
+	"pls":    {"pls", "audio/x-scpls", func() songEncoder { return &plsSongEncoder{} }},
+}
+
+// ExportSongs godoc
+// @Summary Export songs
+// @Description Download every song matching the filter as CSV, NDJSON (one JSON song per line), a JSON array or an
+// @Description M3U8, XSPF or PLS playlist file. The songs are streamed from a consistent snapshot of the library,
+// @Description gzip compressed when the client accepts it. CSV columns are named like the import fields, so an
+// @Description export can be imported again; genres and tags are separated by "|". M3U8 and PLS files leave out
+// @Description songs without a link. An export that fails midway is cut off.
+// @Tags songs
+// @Produce json
+// @Produce text/csv
+// @Produce application/x-ndjson
+// @Produce audio/x-mpegurl
+// @Produce application/xspf+xml
+// @Produce audio/x-scpls
+// @Param format query string false "Export format" Enums(csv, ndjson, json, m3u8, xspf, pls) default(json)
+// @Param group query string false "Filter by group name"
+// @Param artistId query int false "Filter by artist ID"
+// @Param albumId query int false "Filter by album ID"
+// @Param song query string false "Filter by song name"
+// @Param match query string false "Group and song name match mode (exact|prefix|fuzzy)" default(exact)
+// @Param threshold query number false "Minimum similarity for match=fuzzy, 0 to 1; defaults to the server setting"
+// @Param releaseDate query string false "Filter by release date (YYYY-MM-DD)"
+// @Param releasedFrom query string false "Released on or after date (YYYY-MM-DD)"
+// @Param releasedTo query string false "Released on or before date (YYYY-MM-DD)"
+// @Param year query int false "Filter by release year"
+// @Param decade query int false "Filter by release decade, e.g. 1990"
+// @Param text query string false "Search in lyrics"
+// @Param link query string false "Filter by link"
+// @Param genre query []string false "Filter by genre (repeatable)" collectionFormat(multi)
+// @Param genre_match query string false "Genre match mode (any|all)" default(any)
+// @Param tag query []string false "Filter by tag (repeatable)" collectionFormat(multi)
+// @Param tag_match query string false "Tag match mode (any|all)" default(any)
+// @Param sort_by query string false "Sort field (group|song|releaseDate|text|link|score); fuzzy matches default to score"
+// @Param sort_order query string false "Sort order (ASC|DESC)"
+// @Success 200 {array} models.Song
+// @Header 200 {string} Content-Disposition "Attachment with the file name of the export"
+// @Failure 400 {object} errorResponse
+// @Failure 422 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /export [get]
+func (h *Handler) ExportSongs(c *gin.Context) {
+	var filter models.SongFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid filter parameters")
+		return
+	}
+
+	if err := validateSongFilter(filter); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	format, ok := exportFormatParam(c, "json")
+	if !ok {
+		return
+	}
+
+	writeSongFile(c, format, "songs-"+time.Now().Format("20060102-150405"), "Songs", func(each func(models.Song) error) error {
+		return h.services.SongService.ExportSongs(c.Request.Context(), filter, each)
+	})
+}
+
+// exportFormatParam reads the format query parameter, responding with an
+// error when it names no export format.
+func exportFormatParam(c *gin.Context, defaultFormat string) (exportFormat, bool) {
+	format, ok := exportFormats[c.DefaultQuery("format", defaultFormat)]
+	if !ok {
+		newErrorResponse(c, http.StatusBadRequest, "format must be csv, ndjson, json, m3u8, xspf or pls")
+	}
+	return format, ok
+}
+
+// writeSongFile streams the songs passed to each by songs as a file download.
+func writeSongFile(c *gin.Context, format exportFormat, fileName, title string, songs func(each func(models.Song) error) error) {
+	var (
+		encoder    = format.newEncoder()
+		controller = http.NewResponseController(c.Writer)
+		compressor *gzip.Writer
+		started    bool
+		count      int
+	)
+
+	// The server write timeout would also count the time spent fetching the
+	// first songs.
+	extendDeadlines(c, exportWriteTimeout)
+
+	// The response starts with the first song, so that errors found before
+	// it still get an error response.
+	start := func() error {
+		started = true
+		c.Header("Content-Type", format.contentType)
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, fileName, format.extension))
+		c.Header("Vary", "Accept-Encoding")
+
+		var w io.Writer = c.Writer
+		if acceptsGzip(c.GetHeader("Accept-Encoding")) {
+			c.Header("Content-Encoding", "gzip")
+			compressor = gzip.NewWriter(c.Writer)
+			w = compressor
+		}
+		c.Status(http.StatusOK)
+		return encoder.begin(w, title)
+	}
+
+	send := func() error {
+		if err := encoder.flush(); err != nil {
+			return err
+		}
+		if compressor != nil {
+			if err := compressor.Flush(); err != nil {
+				return err
+			}
+		}
+		if err := controller.SetWriteDeadline(time.Now().Add(exportWriteTimeout)); err != nil {
+			logrus.WithError(err).Debug("Export write deadline not extended")
+		}
+		return controller.Flush()
+	}
+
+	err := songs(func(song models.Song) error {
+		if !started {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+		if err := encoder.encode(song); err != nil {
+			return err
+		}
+		if count++; count%exportFlushEvery == 0 {
+			return send()
+		}
+		return nil
+	})
+	if err == nil && !started {
+		err = start()
+	}
+	if err == nil {
+		err = encoder.end()
+	}
+	if err == nil && compressor != nil {
+		err = compressor.Close()
+	}
+
+	if err != nil {
+		if !started {
+			serviceErrorResponse(c, err, "Songs export error")
+			return
+		}
+		// The status is already sent, so the only way left to tell the
+		// client the export is incomplete is to cut the response off.
+		logrus.WithError(err).WithField("songs", count).Error("Songs export failed midway")
+		panic(http.ErrAbortHandler)
+	}
+
+	logrus.WithFields(logrus.Fields{"songs": count, "format": format.extension}).Info("Songs exported")
+}
+
+// acceptsGzip tells whether an Accept-Encoding header allows gzip.
+func acceptsGzip(header string) bool {
+	for _, part := range strings.Split(header, ",") {
+		coding, params, _ := strings.Cut(part, ";")
+		if !strings.EqualFold(strings.TrimSpace(coding), "gzip") {
+			continue
+		}
+		quality, found := strings.CutPrefix(strings.TrimSpace(params), "q=")
+		if !found {
+			return true
+		}
+		q, err := strconv.ParseFloat(quality, 64)
+		return err == nil && q > 0
+	}
+	return false
+}
+
+type csvSongEncoder struct {
+	writer *csv.Writer
+}
+
+func (e *csvSongEncoder) begin(w io.Writer, title string) error {
+	e.writer = csv.NewWriter(w)
+	return e.writer.Write([]string{"id", "group", "song", "releaseDate", "text", "link", "albumId", "disc", "track", "genres", "tags", "version"})
+}
+
+func (e *csvSongEncoder) encode(song models.Song) error {
+	return e.writer.Write([]string{
+		strconv.Itoa(song.ID),
+		song.Group,
+		song.SongName,
+		song.ReleaseDate,
+		song.Text,
+		song.Link,
+		optionalInt(song.AlbumID),
+		optionalInt(song.DiscNumber),
+		optionalInt(song.TrackNumber),
+		strings.Join(song.Genres, exportListSeparator),
+		strings.Join(song.Tags, exportListSeparator),
+		strconv.Itoa(song.Version),
+	})
+}
+
+func (e *csvSongEncoder) flush() error {
+	e.writer.Flush()
+	return e.writer.Error()
+}
+
+func (e *csvSongEncoder) end() error {
+	return e.flush()
+}
+
+func optionalInt(value *int) string {
+	if value == nil {
+		return ""
+	}
+	return strconv.Itoa(*value)
+}
+
+type ndjsonSongEncoder struct {
+	encoder *json.Encoder
+}
+
+func (e *ndjsonSongEncoder) begin(w io.Writer, title string) error {
+	e.encoder = json.NewEncoder(w)
+	return nil
+}
+
+func (e *ndjsonSongEncoder) encode(song models.Song) error {
+	return e.encoder.Encode(song)
+}
+
+func (e *ndjsonSongEncoder) flush() error { return nil }
+
+func (e *ndjsonSongEncoder) end() error { return nil }
+
+// jsonSongEncoder writes the songs as one JSON array, a song per line.
+type jsonSongEncoder struct {
+	w     io.Writer
+	count int
+}
+
+func (e *jsonSongEncoder) begin(w io.Writer, title string) error {
+	e.w = w
+	_, err := io.WriteString(w, "[")
+	return err
+}
+
+func (e *jsonSongEncoder) encode(song models.Song) error {
+	data, err := json.Marshal(song)
+	if err != nil {
+		return err
+	}
+
+	separator := "\n"
+	if e.count > 0 {
+		separator = ",\n"
+	}
+	e.count++
+
+	if _, err := io.WriteString(e.w, separator); err != nil {
+		return err
+	}
+	_, err = e.w.Write(data)
+	return err
+}
+
+func (e *jsonSongEncoder) flush() error { return nil }
+
+func (e *jsonSongEncoder) end() error {
+	_, err := io.WriteString(e.w, "\n]\n")
+	return err
+}
@@ -19,17 +19,83 @@ func NewHandler(services *service.Service) *Handler {
 
 func (h *Handler) InitRoutes() *gin.Engine {
 	router := gin.New()
+	router.Use(requestId)
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
 	api := router.Group("/songs")
 	{
 		api.GET("", h.GetSongs)
-		api.POST("", h.CreateSong)
+		api.POST("", h.idempotent, h.CreateSong)
+		api.POST("/batch", h.idempotent, h.ApplySongBatch)
 		api.GET("/:id", h.GetSongById)
 		api.PUT("/:id", h.UpdateSongById)
+		api.PATCH("/:id", h.PatchSongById)
 		api.DELETE("/:id", h.DeleteSongById)
+		api.POST("/:id/merge", h.MergeSongs)
+		api.POST("/:id/restore", h.RestoreSong)
+		api.GET("/:id/history", h.GetSongHistory)
+		api.POST("/:id/revert/:revision", h.RevertSong)
 		api.GET("/:id/lyrics", h.GetSongLyrics)
 		api.GET("/generate", h.GenerateFakeSongs)
+		api.GET("/search", h.SearchSongs)
+		api.GET("/duplicates", h.GetSongDuplicates)
+		api.GET("/trash", h.GetTrashedSongs)
+		api.POST("/:id/tags", h.AddSongTags)
+		api.DELETE("/:id/tags/:tag", h.RemoveSongTag)
+		api.POST("/:id/genres", h.AddSongGenres)
+		api.DELETE("/:id/genres/:genre", h.RemoveSongGenre)
+	}
+
+	playlists := router.Group("/playlists")
+	{
+		playlists.GET("", h.GetPlaylists)
+		playlists.POST("", h.CreatePlaylist)
+		playlists.POST("/import", h.ImportPlaylist)
+		playlists.GET("/:id", h.GetPlaylistById)
+		playlists.GET("/:id/export", h.ExportPlaylist)
+		playlists.PUT("/:id", h.RenamePlaylist)
+		playlists.DELETE("/:id", h.DeletePlaylist)
+		playlists.POST("/:id/entries", h.AddPlaylistEntry)
+		playlists.PUT("/:id/entries/:entryId", h.MovePlaylistEntry)
+		playlists.DELETE("/:id/entries/:entryId", h.RemovePlaylistEntry)
+	}
+
+	smartPlaylists := router.Group("/smart-playlists")
+	{
+		smartPlaylists.GET("", h.GetSmartPlaylists)
+		smartPlaylists.POST("", h.CreateSmartPlaylist)
+		smartPlaylists.GET("/:id", h.GetSmartPlaylistById)
+		smartPlaylists.PUT("/:id", h.UpdateSmartPlaylist)
+		smartPlaylists.DELETE("/:id", h.DeleteSmartPlaylist)
+		smartPlaylists.GET("/:id/songs", h.GetSmartPlaylistSongs)
+	}
+
+	router.GET("/tags", h.GetTags)
+	router.GET("/genres", h.GetGenres)
+	router.GET("/suggest", h.Suggest)
+	router.POST("/import", h.ImportSongs)
+	router.GET("/export", h.ExportSongs)
+
+	artists := router.Group("/artists")
+	{
+		artists.GET("", h.GetArtists)
+		artists.POST("", h.CreateArtist)
+		artists.GET("/:id", h.GetArtistById)
+		artists.PUT("/:id", h.UpdateArtistById)
+		artists.DELETE("/:id", h.DeleteArtistById)
+		artists.GET("/:id/songs", h.GetArtistSongs)
+	}
+
+	albums := router.Group("/albums")
+	{
+		albums.GET("", h.GetAlbums)
+		albums.POST("", h.CreateAlbum)
+		albums.GET("/:id", h.GetAlbumById)
+		albums.PUT("/:id", h.UpdateAlbumById)
+		albums.DELETE("/:id", h.DeleteAlbumById)
+		albums.GET("/:id/tracks", h.GetAlbumTracks)
+		albums.PUT("/:id/tracks/:songId", h.SetAlbumTrack)
+		albums.DELETE("/:id/tracks/:songId", h.RemoveAlbumTrack)
 	}
 
 	return router
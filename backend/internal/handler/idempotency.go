@@ -0,0 +1,140 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/AntonZatsepilin/music-library.git/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	idempotencyKeyHeader = "Idempotency-Key"
+	maxIdempotencyKeyLen = 255
+)
+
+// replayedHeaders are the response headers saved with an idempotent response.
+var replayedHeaders = []string{"Content-Type", "Location", "ETag"}
+
+// responseRecorder keeps a copy of the response body written by a handler.
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// idempotent makes a write endpoint safe to retry. A request sent with an
+// Idempotency-Key header is processed once; retries with the same key get
+// the saved response back. Server errors are not saved, so such requests
+// can be retried with the same key. Once the handler has succeeded the key
+// is kept even if its response cannot be saved, so a retry never repeats the
+// write. The key's lease is renewed while the handler runs, and a request
+// whose key was taken over leaves it to the request that took it. Requests
+// without the header pass through.
+func (h *Handler) idempotent(c *gin.Context) {
+	key := strings.TrimSpace(c.GetHeader(idempotencyKeyHeader))
+	if key == "" {
+		c.Next()
+		return
+	}
+
+	if len(key) > maxIdempotencyKeyLen {
+		newErrorResponse(c, http.StatusBadRequest, "Idempotency-Key must be at most 255 characters")
+		return
+	}
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "failed to read request body")
+		return
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+	// Keys are only unique per endpoint and caller.
+	scope := c.Request.Method + " " + c.FullPath() + " " + c.GetHeader(userIdHeader)
+	hash := sha256.Sum256(append([]byte(c.Request.URL.RawQuery+"\n"), body...))
+
+	idempotency := h.services.IdempotencyService
+	saved, token, err := idempotency.BeginIdempotentRequest(scope, key, hex.EncodeToString(hash[:]))
+	if err != nil {
+		serviceErrorResponse(c, err, "Idempotency key error")
+		return
+	}
+
+	if saved != nil {
+		logrus.WithField("key", key).Info("Replaying the response to a repeated request")
+		for name, value := range saved.Headers {
+			c.Header(name, value)
+		}
+		c.Header("Idempotent-Replayed", "true")
+		c.Status(saved.Status)
+		c.Writer.Write(saved.Body)
+		c.Abort()
+		return
+	}
+
+	recorder := &responseRecorder{ResponseWriter: c.Writer}
+	c.Writer = recorder
+
+	handled := false
+	defer func() {
+		if !handled {
+			if err := idempotency.AbandonIdempotentRequest(scope, key, token); err != nil {
+				logrus.WithError(err).Error("Error releasing idempotency key")
+			}
+		}
+	}()
+
+	ctx, stopHold := context.WithCancel(context.Background())
+	go idempotency.HoldIdempotentRequest(ctx, scope, key, token)
+	defer stopHold()
+
+	c.Next()
+
+	if recorder.Status() >= http.StatusInternalServerError {
+		return
+	}
+	handled = true
+
+	response := models.IdempotentResponse{
+		Status:  recorder.Status(),
+		Headers: make(map[string]string),
+		Body:    recorder.body.Bytes(),
+	}
+	for _, name := range replayedHeaders {
+		if value := recorder.Header().Get(name); value != "" {
+			response.Headers[name] = value
+		}
+	}
+
+	if err := idempotency.CompleteIdempotentRequest(scope, key, token, response); err != nil {
+		logrus.WithError(err).Error("Error saving idempotent response")
+		if errors.Is(err, models.ErrConflict) {
+			return
+		}
+
+		// Retries then get the status and headers, such as the Location of a
+		// created song, without the body.
+		response.Body = nil
+		delete(response.Headers, "Content-Type")
+		if err := idempotency.CompleteIdempotentRequest(scope, key, token, response); err != nil {
+			logrus.WithError(err).Error("Error completing idempotency key")
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/AntonZatsepilin/music-library.git/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// importTimeout replaces the server read and write timeouts for imports,
+// whose bodies can take much longer to upload and process.
+const importTimeout = 10 * time.Minute
+
+// importFormats are the import formats by request Content-Type.
+var importFormats = map[string]string{
+	"text/csv":                models.ImportCSV,
+	"application/csv":         models.ImportCSV,
+	"application/x-ndjson":    models.ImportNDJSON,
+	"application/ndjson":      models.ImportNDJSON,
+	"application/jsonl":       models.ImportNDJSON,
+	"application/x-jsonlines": models.ImportNDJSON,
+}
+
+// ImportSongs godoc
+// @Summary Import songs
+// @Description Import a song catalogue from CSV or NDJSON (one JSON song per line). The body is read as a stream and
+// @Description every line is checked on its own: lines that fail are reported with their error and the others are
+// @Description saved. CSV columns are found by header name or 1-based position; by default they are named after the
+// @Description fields or, with header=false, come in the order group, song, releaseDate, text, link.
+// @Description With enrich the music info API fills in the fields a line leaves empty. With dryRun nothing is saved
+// @Description and songs that already exist or repeat an earlier line are reported as conflicts.
+// @Tags songs
+// @Accept text/csv
+// @Accept application/x-ndjson
+// @Produce json
+// @Param format query string false "csv or ndjson; taken from the Content-Type when omitted" Enums(csv, ndjson)
+// @Param delimiter query string false "CSV field separator" default(,)
+// @Param header query bool false "Whether the first CSV line names the columns" default(true)
+// @Param group query string false "CSV column of the group"
+// @Param song query string false "CSV column of the song name"
+// @Param releaseDate query string false "CSV column of the release date"
+// @Param text query string false "CSV column of the lyrics"
+// @Param link query string false "CSV column of the link"
+// @Param enrich query bool false "Fill in empty fields from the music info API"
+// @Param dryRun query bool false "Check the lines without saving anything"
+// @Param input body string true "Songs"
+// @Success 200 {object} models.ImportResponse
+// @Failure 400 {object} errorResponse
+// @Failure 415 {object} errorResponse
+// @Failure 422 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /import [post]
+func (h *Handler) ImportSongs(c *gin.Context) {
+	logrus.Debug("Received a song import")
+
+	var options models.ImportOptions
+	if err := c.ShouldBindQuery(&options); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid import parameters")
+		return
+	}
+
+	if options.Format == "" {
+		format, ok := importFormats[c.ContentType()]
+		if !ok {
+			newErrorResponse(c, http.StatusUnsupportedMediaType, "import takes text/csv or application/x-ndjson")
+			return
+		}
+		options.Format = format
+	}
+
+	extendDeadlines(c, importTimeout)
+
+	result, err := h.services.SongService.ImportSongs(c.Request.Body, options, getAudit(c))
+	if err != nil {
+		serviceErrorResponse(c, err, "Song import error")
+		return
+	}
+
+	response := models.ImportResponse{
+		DryRun:   result.DryRun,
+		Lines:    result.Lines,
+		Imported: result.Imported,
+		Failed:   result.Failed,
+		Errors:   make([]models.ImportLineError, 0, len(result.Failures)),
+		Preview:  result.Preview,
+	}
+	for _, failure := range result.Failures {
+		_, description, ok := describeError(failure.Err)
+		if !ok {
+			logrus.WithError(failure.Err).WithField("line", failure.Line).Error("Song import line error")
+		}
+		response.Errors = append(response.Errors, models.ImportLineError{
+			Line:       failure.Line,
+			Error:      description.errorText(),
+			Code:       description.Code,
+			ExistingID: description.ExistingID,
+		})
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// extendDeadlines gives the request timeout to read its body and respond in
+// place of the server timeouts.
+func extendDeadlines(c *gin.Context, timeout time.Duration) {
+	controller := http.NewResponseController(c.Writer)
+	deadline := time.Now().Add(timeout)
+	if err := controller.SetReadDeadline(deadline); err != nil {
+		logrus.WithError(err).Debug("Read deadline not extended")
+	}
+	if err := controller.SetWriteDeadline(deadline); err != nil {
+		logrus.WithError(err).Debug("Write deadline not extended")
+	}
+}
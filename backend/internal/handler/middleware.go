@@ -1,20 +1,99 @@
 package handler
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"net/http"
 	"strconv"
+	"strings"
 
+	"github.com/AntonZatsepilin/music-library.git/internal/models"
 	"github.com/gin-gonic/gin"
 )
 
+const (
+	userIdHeader       = "X-User-ID"
+	requestIdHeader    = "X-Request-ID"
+	requestIdKey       = "requestId"
+	maxRequestIdLength = 128
+)
+
+// requestId tags every request with the X-Request-ID sent by the client, or a
+// new random id, and echoes it in the response.
+func requestId(c *gin.Context) {
+    id := strings.TrimSpace(c.GetHeader(requestIdHeader))
+    if id == "" || len(id) > maxRequestIdLength {
+        buf := make([]byte, 16)
+        rand.Read(buf)
+        id = hex.EncodeToString(buf)
+    }
+
+    c.Set(requestIdKey, id)
+    c.Header(requestIdHeader, id)
+    c.Next()
+}
+
+// getAudit names the caller and request of a song change for its history.
+// Callers that send no X-User-ID are recorded without an actor.
+func getAudit(c *gin.Context) models.Audit {
+    return models.Audit{
+        Actor:     strings.TrimSpace(c.GetHeader(userIdHeader)),
+        RequestID: c.GetString(requestIdKey),
+    }
+}
+
 func getSongId(c *gin.Context) (int, error) {
-    idParam := c.Param("id")
+    return getIdParam(c, "id", "song")
+}
+
+func getArtistId(c *gin.Context) (int, error) {
+    return getIdParam(c, "id", "artist")
+}
+
+func getAlbumId(c *gin.Context) (int, error) {
+    return getIdParam(c, "id", "album")
+}
+
+func getPlaylistId(c *gin.Context) (int, error) {
+    return getIdParam(c, "id", "playlist")
+}
+
+// getUserId returns the caller identity passed in the X-User-ID header.
+func getUserId(c *gin.Context) (string, error) {
+    userId := strings.TrimSpace(c.GetHeader(userIdHeader))
+    if userId == "" {
+        newErrorResponse(c, http.StatusUnauthorized, "missing "+userIdHeader+" header")
+        return "", errors.New("missing user id")
+    }
+
+    return userId, nil
+}
+
+func getIdParam(c *gin.Context, param, entity string) (int, error) {
+    idParam := c.Param(param)
     id, err := strconv.Atoi(idParam)
     if err != nil {
-        newErrorResponse(c, http.StatusBadRequest, "invalid song id")
-        return 0, errors.New("invalid song id")
+        message := "invalid " + entity + " id"
+        newErrorResponse(c, http.StatusBadRequest, message)
+        return 0, errors.New(message)
     }
 
     return id, nil
-}
\ No newline at end of file
+}
+
+func getPagination(c *gin.Context) (int, int, error) {
+    page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+    if err != nil || page < 1 {
+        newErrorResponse(c, http.StatusBadRequest, "invalid page number")
+        return 0, 0, errors.New("invalid page number")
+    }
+
+    limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+    if err != nil || limit < 1 || limit > 100 {
+        newErrorResponse(c, http.StatusBadRequest, "limit must be between 1 and 100")
+        return 0, 0, errors.New("invalid limit value")
+    }
+
+    return page, limit, nil
+}
@@ -0,0 +1,238 @@
+package handler
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/AntonZatsepilin/music-library.git/internal/models"
+)
+
+// maxPlaylistLineSize is the longest line an imported M3U file may have.
+const maxPlaylistLineSize = 64 * 1024
+
+// Playlist file formats point players at the song links. M3U8 and PLS entries
+// are nothing but a location, so songs without a link are left out of them.
+
+// m3u8SongEncoder writes an extended M3U playlist in UTF-8.
+type m3u8SongEncoder struct {
+	w io.Writer
+}
+
+func (e *m3u8SongEncoder) begin(w io.Writer, title string) error {
+	e.w = w
+	_, err := fmt.Fprintf(w, "#EXTM3U\n#PLAYLIST:%s\n", singleLine(title))
+	return err
+}
+
+func (e *m3u8SongEncoder) encode(song models.Song) error {
+	if song.Link == "" {
+		return nil
+	}
+	_, err := fmt.Fprintf(e.w, "#EXTINF:-1,%s\n%s\n", songLabel(song), singleLine(song.Link))
+	return err
+}
+
+func (e *m3u8SongEncoder) flush() error { return nil }
+
+func (e *m3u8SongEncoder) end() error { return nil }
+
+// plsSongEncoder writes a PLS playlist. Its entry count comes last, so the
+// file can be written as the songs arrive.
+type plsSongEncoder struct {
+	w     io.Writer
+	count int
+}
+
+func (e *plsSongEncoder) begin(w io.Writer, title string) error {
+	e.w = w
+	_, err := io.WriteString(w, "[playlist]\n")
+	return err
+}
+
+func (e *plsSongEncoder) encode(song models.Song) error {
+	if song.Link == "" {
+		return nil
+	}
+	e.count++
+	_, err := fmt.Fprintf(e.w, "File%[1]d=%[2]s\nTitle%[1]d=%[3]s\nLength%[1]d=-1\n", e.count, singleLine(song.Link), songLabel(song))
+	return err
+}
+
+func (e *plsSongEncoder) flush() error { return nil }
+
+func (e *plsSongEncoder) end() error {
+	_, err := fmt.Fprintf(e.w, "NumberOfEntries=%d\nVersion=2\n", e.count)
+	return err
+}
+
+// xspfSongEncoder writes an XSPF playlist, a track per song.
+type xspfSongEncoder struct {
+	w       io.Writer
+	encoder *xml.Encoder
+	count   int
+}
+
+type xspfTrack struct {
+	XMLName  xml.Name `xml:"track"`
+	Location string   `xml:"location,omitempty"`
+	Creator  string   `xml:"creator"`
+	Title    string   `xml:"title"`
+}
+
+func (e *xspfSongEncoder) begin(w io.Writer, title string) error {
+	e.w = w
+	e.encoder = xml.NewEncoder(w)
+	e.encoder.Indent("    ", "  ")
+
+	if _, err := io.WriteString(w, xml.Header+`<playlist version="1" xmlns="http://xspf.org/ns/0/">`+"\n  <title>"); err != nil {
+		return err
+	}
+	if err := xml.EscapeText(w, []byte(title)); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "</title>\n  <trackList>\n")
+	return err
+}
+
+func (e *xspfSongEncoder) encode(song models.Song) error {
+	e.count++
+	return e.encoder.Encode(xspfTrack{Location: song.Link, Creator: song.Group, Title: song.SongName})
+}
+
+func (e *xspfSongEncoder) flush() error { return nil }
+
+func (e *xspfSongEncoder) end() error {
+	end := "  </trackList>\n</playlist>\n"
+	if e.count > 0 {
+		end = "\n" + end
+	}
+	_, err := io.WriteString(e.w, end)
+	return err
+}
+
+// parseM3U reads a plain or extended M3U playlist. Entries take their creator
+// and title from "#EXTINF:duration,creator - title" or, without it, from the
+// file name of the location.
+func parseM3U(r io.Reader) (models.PlaylistFile, error) {
+	var (
+		file    models.PlaylistFile
+		label   string
+		labeled bool
+	)
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 4096), maxPlaylistLineSize)
+	for first := true; scanner.Scan(); first = false {
+		line := latin1ToUTF8(scanner.Bytes())
+		if first {
+			line = strings.TrimPrefix(line, "\ufeff")
+		}
+		line = strings.TrimSpace(line)
+
+		switch {
+		case line == "":
+		case strings.HasPrefix(line, "#EXTINF:"):
+			_, label, labeled = strings.Cut(strings.TrimPrefix(line, "#EXTINF:"), ",")
+		case strings.HasPrefix(line, "#PLAYLIST:"):
+			file.Title = strings.TrimSpace(strings.TrimPrefix(line, "#PLAYLIST:"))
+		case strings.HasPrefix(line, "#"):
+		default:
+			if !labeled {
+				label = locationLabel(line)
+			}
+			creator, title := splitSongLabel(label)
+			file.Entries = append(file.Entries, models.PlaylistFileEntry{Location: line, Creator: creator, Title: title})
+			label, labeled = "", false
+		}
+	}
+
+	return file, scanner.Err()
+}
+
+// latin1ToUTF8 decodes a line of an M3U file. Extended M3U8 files are UTF-8,
+// but plain M3U files are often Latin-1.
+func latin1ToUTF8(line []byte) string {
+	if utf8.Valid(line) {
+		return string(line)
+	}
+	runes := make([]rune, len(line))
+	for i, b := range line {
+		runes[i] = rune(b)
+	}
+	return string(runes)
+}
+
+// locationLabel takes the "creator - title" label from the name of an audio
+// file. Locations without a file extension, such as web pages, give none.
+func locationLabel(location string) string {
+	name := strings.ReplaceAll(location, "\\", "/")
+	if u, err := url.Parse(name); err == nil && u.Scheme != "" {
+		name = u.Path
+	} else if unescaped, err := url.PathUnescape(name); err == nil {
+		name = unescaped
+	}
+
+	name = path.Base(name)
+	extension := path.Ext(name)
+	if extension == "" || extension == name {
+		return ""
+	}
+	return strings.TrimSuffix(name, extension)
+}
+
+// splitSongLabel splits a "creator - title" label. A label without the
+// separator is taken to be a title.
+func splitSongLabel(label string) (string, string) {
+	for _, separator := range []string{" - ", " – ", " — "} {
+		if creator, title, found := strings.Cut(label, separator); found {
+			return strings.TrimSpace(creator), strings.TrimSpace(title)
+		}
+	}
+	return "", strings.TrimSpace(label)
+}
+
+// xspfPlaylist is the part of an XSPF playlist an import reads. A track may
+// list several locations of the same song; the first one is used.
+type xspfPlaylist struct {
+	XMLName xml.Name `xml:"playlist"`
+	Title   string   `xml:"title"`
+	Tracks  []struct {
+		Locations []string `xml:"location"`
+		Creator   string   `xml:"creator"`
+		Title     string   `xml:"title"`
+	} `xml:"trackList>track"`
+}
+
+func parseXSPF(r io.Reader) (models.PlaylistFile, error) {
+	var playlist xspfPlaylist
+	if err := xml.NewDecoder(r).Decode(&playlist); err != nil {
+		return models.PlaylistFile{}, err
+	}
+
+	file := models.PlaylistFile{Title: strings.TrimSpace(playlist.Title)}
+	for _, track := range playlist.Tracks {
+		entry := models.PlaylistFileEntry{Creator: track.Creator, Title: track.Title}
+		if len(track.Locations) > 0 {
+			entry.Location = strings.TrimSpace(track.Locations[0])
+		}
+		file.Entries = append(file.Entries, entry)
+	}
+
+	return file, nil
+}
+
+// songLabel is the "group - song" display title of a song.
+func songLabel(song models.Song) string {
+	return singleLine(song.Group + " - " + song.SongName)
+}
+
+// singleLine keeps a value from breaking the line-based playlist formats.
+func singleLine(value string) string {
+	return strings.Join(strings.Fields(value), " ")
+}
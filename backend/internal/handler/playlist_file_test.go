@@ -0,0 +1,210 @@
+package handler
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/AntonZatsepilin/music-library.git/internal/models"
+)
+
+func TestParseM3U(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    models.PlaylistFile
+		wantErr bool
+	}{
+		{
+			name:  "extended",
+			input: "#EXTM3U\n#PLAYLIST: Road trip \n#EXTINF:123,Muse - Hysteria\nhttps://example.com/hysteria\n",
+			want: models.PlaylistFile{Title: "Road trip", Entries: []models.PlaylistFileEntry{
+				{Location: "https://example.com/hysteria", Creator: "Muse", Title: "Hysteria"},
+			}},
+		},
+		{
+			name:  "byte order mark and CRLF",
+			input: "\ufeff#EXTM3U\r\n#EXTINF:-1,Queen – Bohemian Rhapsody\r\nC:\\Music\\queen.mp3\r\n",
+			want: models.PlaylistFile{Entries: []models.PlaylistFileEntry{
+				{Location: `C:\Music\queen.mp3`, Creator: "Queen", Title: "Bohemian Rhapsody"},
+			}},
+		},
+		{
+			name:  "plain",
+			input: "/music/Muse - Hysteria.mp3\n\n  relative/Queen%20-%20Innuendo.flac  \nhttps://example.com/watch?v=1\n",
+			want: models.PlaylistFile{Entries: []models.PlaylistFileEntry{
+				{Location: "/music/Muse - Hysteria.mp3", Creator: "Muse", Title: "Hysteria"},
+				{Location: "relative/Queen%20-%20Innuendo.flac", Creator: "Queen", Title: "Innuendo"},
+				{Location: "https://example.com/watch?v=1"},
+			}},
+		},
+		{
+			name:  "label kept across directives",
+			input: "#EXTINF:200,Muse - Hysteria, live\n#EXTVLCOPT:network-caching=1000\nhysteria.mp3\nplain.mp3\n",
+			want: models.PlaylistFile{Entries: []models.PlaylistFileEntry{
+				{Location: "hysteria.mp3", Creator: "Muse", Title: "Hysteria, live"},
+				{Location: "plain.mp3", Title: "plain"},
+			}},
+		},
+		{
+			name:  "extinf without label",
+			input: "#EXTINF:200\nMuse - Hysteria.mp3\n",
+			want: models.PlaylistFile{Entries: []models.PlaylistFileEntry{
+				{Location: "Muse - Hysteria.mp3", Creator: "Muse", Title: "Hysteria"},
+			}},
+		},
+		{
+			name:  "latin-1",
+			input: "#EXTINF:-1,Beyonc\xe9 - D\xe9j\xe0 Vu\nbeyonce.mp3\n",
+			want: models.PlaylistFile{Entries: []models.PlaylistFileEntry{
+				{Location: "beyonce.mp3", Creator: "Beyoncé", Title: "Déjà Vu"},
+			}},
+		},
+		{
+			name:  "no entries",
+			input: "#EXTM3U\n#EXTINF:-1,Muse - Hysteria\n",
+			want:  models.PlaylistFile{},
+		},
+		{
+			name:    "line too long",
+			input:   "a.mp3\n" + strings.Repeat("a", maxPlaylistLineSize+1) + ".mp3\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseM3U(strings.NewReader(tt.input))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseM3U() = %+v, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseM3U() unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseM3U() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseXSPF(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    models.PlaylistFile
+		wantErr bool
+	}{
+		{
+			name: "playlist",
+			input: `<?xml version="1.0" encoding="UTF-8"?>
+<playlist version="1" xmlns="http://xspf.org/ns/0/">
+  <title> Road trip </title>
+  <trackList>
+    <track>
+      <location> https://example.com/hysteria </location>
+      <location>https://mirror.example.com/hysteria</location>
+      <creator>Muse</creator>
+      <title>Hysteria</title>
+    </track>
+    <track>
+      <creator>Queen &amp; David Bowie</creator>
+      <title><![CDATA[Under Pressure]]></title>
+    </track>
+  </trackList>
+</playlist>`,
+			want: models.PlaylistFile{Title: "Road trip", Entries: []models.PlaylistFileEntry{
+				{Location: "https://example.com/hysteria", Creator: "Muse", Title: "Hysteria"},
+				{Creator: "Queen & David Bowie", Title: "Under Pressure"},
+			}},
+		},
+		{
+			name:  "byte order mark and CRLF",
+			input: "\ufeff<?xml version=\"1.0\"?>\r\n<playlist version=\"1\">\r\n<trackList><track><title>Hysteria</title></track></trackList>\r\n</playlist>\r\n",
+			want:  models.PlaylistFile{Entries: []models.PlaylistFileEntry{{Title: "Hysteria"}}},
+		},
+		{
+			name:  "empty track list",
+			input: `<playlist version="1" xmlns="http://xspf.org/ns/0/"><trackList/></playlist>`,
+			want:  models.PlaylistFile{},
+		},
+		{name: "empty", input: "", wantErr: true},
+		{name: "not xml", input: "#EXTM3U\nhysteria.mp3\n", wantErr: true},
+		{name: "other document", input: "<html><body/></html>", wantErr: true},
+		{name: "unclosed", input: `<playlist version="1"><trackList><track>`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseXSPF(strings.NewReader(tt.input))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseXSPF() = %+v, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseXSPF() unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseXSPF() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLocationLabel(t *testing.T) {
+	tests := []struct {
+		location string
+		want     string
+	}{
+		{location: "Muse - Hysteria.mp3", want: "Muse - Hysteria"},
+		{location: "/music/Muse - Hysteria.mp3", want: "Muse - Hysteria"},
+		{location: `C:\Music\Muse - Hysteria.flac`, want: "Muse - Hysteria"},
+		{location: "file:///music/Muse%20-%20Hysteria.ogg", want: "Muse - Hysteria"},
+		{location: "https://example.com/songs/Muse%20-%20Hysteria.mp3?token=1", want: "Muse - Hysteria"},
+		{location: "relative/Muse%20-%20Hysteria.mp3", want: "Muse - Hysteria"},
+		{location: "bad%zzescape.mp3", want: "bad%zzescape"},
+		{location: "archive.tar.gz", want: "archive.tar"},
+		{location: "https://example.com/watch?v=1", want: ""},
+		{location: "https://example.com/", want: ""},
+		{location: ".hidden", want: ""},
+		{location: "no-extension", want: ""},
+		{location: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		if got := locationLabel(tt.location); got != tt.want {
+			t.Errorf("locationLabel(%q) = %q, want %q", tt.location, got, tt.want)
+		}
+	}
+}
+
+func TestSplitSongLabel(t *testing.T) {
+	tests := []struct {
+		label       string
+		wantCreator string
+		wantTitle   string
+	}{
+		{label: "Muse - Hysteria", wantCreator: "Muse", wantTitle: "Hysteria"},
+		{label: "  Muse  -  Hysteria  ", wantCreator: "Muse", wantTitle: "Hysteria"},
+		{label: "Queen – Bohemian Rhapsody", wantCreator: "Queen", wantTitle: "Bohemian Rhapsody"},
+		{label: "Queen — Bohemian Rhapsody", wantCreator: "Queen", wantTitle: "Bohemian Rhapsody"},
+		{label: "AC/DC - Back in Black - Live", wantCreator: "AC/DC", wantTitle: "Back in Black - Live"},
+		{label: "Jay-Z - 99 Problems", wantCreator: "Jay-Z", wantTitle: "99 Problems"},
+		{label: "Hysteria", wantTitle: "Hysteria"},
+		{label: "Muse-Hysteria", wantTitle: "Muse-Hysteria"},
+		{label: " - Hysteria", wantTitle: "Hysteria"},
+		{label: "", wantTitle: ""},
+	}
+
+	for _, tt := range tests {
+		creator, title := splitSongLabel(tt.label)
+		if creator != tt.wantCreator || title != tt.wantTitle {
+			t.Errorf("splitSongLabel(%q) = %q, %q, want %q, %q", tt.label, creator, title, tt.wantCreator, tt.wantTitle)
+		}
+	}
+}
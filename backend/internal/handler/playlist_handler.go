@@ -0,0 +1,489 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/AntonZatsepilin/music-library.git/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// CreatePlaylist godoc
+// @Summary Create new playlist
+// @Description Create an empty playlist owned by the calling user
+// @Tags playlists
+// @Accept json
+// @Produce json
+// @Param X-User-ID header string true "User ID"
+// @Param input body models.CreatePlaylistRequest true "Playlist data"
+// @Success 201 {object} models.Playlist
+// @Failure 400 {object} errorResponse
+// @Failure 401 {object} errorResponse
+// @Failure 422 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /playlists [post]
+func (h *Handler) CreatePlaylist(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		return
+	}
+
+	var input models.CreatePlaylistRequest
+
+	if err := c.BindJSON(&input); err != nil {
+		logrus.WithError(err).Warn("Invalid request format")
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	playlist, err := h.services.PlaylistService.CreatePlaylist(userId, input)
+	if err != nil {
+		serviceErrorResponse(c, err, "Playlist creation error")
+		return
+	}
+
+	logrus.Info("Playlist created successfully")
+	c.JSON(http.StatusCreated, playlist)
+}
+
+// maxPlaylistFileSize is the largest playlist file an import reads.
+const maxPlaylistFileSize = 10 << 20
+
+// playlistFileFormats are the playlist import formats by request Content-Type.
+var playlistFileFormats = map[string]string{
+	"audio/x-mpegurl":               models.PlaylistFileM3U,
+	"audio/mpegurl":                 models.PlaylistFileM3U,
+	"application/x-mpegurl":         models.PlaylistFileM3U,
+	"application/vnd.apple.mpegurl": models.PlaylistFileM3U,
+	"application/xspf+xml":          models.PlaylistFileXSPF,
+}
+
+// playlistFileParsers read the playlist import formats.
+var playlistFileParsers = map[string]func(io.Reader) (models.PlaylistFile, error){
+	models.PlaylistFileM3U:  parseM3U,
+	"m3u8":                  parseM3U,
+	models.PlaylistFileXSPF: parseXSPF,
+}
+
+// ImportPlaylist godoc
+// @Summary Import playlist
+// @Description Create a playlist from an M3U, M3U8 or XSPF file. Each entry is matched to a library song by its link,
+// @Description then by its artist and title, exactly and then fuzzily. With create the entries that match nothing are
+// @Description added to the library through the music info API. The response reports how every entry was resolved;
+// @Description the playlist holds the matched and created songs in file order. When no entry matches a song no playlist
+// @Description is created and the report comes back with status 200.
+// @Tags playlists
+// @Accept audio/x-mpegurl
+// @Accept application/xspf+xml
+// @Produce json
+// @Param X-User-ID header string true "User ID"
+// @Param format query string false "File format; taken from the Content-Type when omitted" Enums(m3u, m3u8, xspf)
+// @Param name query string false "Playlist name; the title in the file by default"
+// @Param create query bool false "Create the songs that match nothing"
+// @Param threshold query number false "Minimum similarity of a fuzzy match" minimum(0) maximum(1)
+// @Param input body string true "Playlist file"
+// @Success 200 {object} models.PlaylistImportResponse
+// @Success 201 {object} models.PlaylistImportResponse
+// @Failure 400 {object} errorResponse
+// @Failure 401 {object} errorResponse
+// @Failure 413 {object} errorResponse
+// @Failure 415 {object} errorResponse
+// @Failure 422 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /playlists/import [post]
+func (h *Handler) ImportPlaylist(c *gin.Context) {
+	logrus.Debug("Received a playlist import")
+
+	userId, err := getUserId(c)
+	if err != nil {
+		return
+	}
+
+	var options models.PlaylistImportOptions
+	if err := c.ShouldBindQuery(&options); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid import parameters")
+		return
+	}
+
+	if options.Format == "" {
+		format, ok := playlistFileFormats[c.ContentType()]
+		if !ok {
+			newErrorResponse(c, http.StatusUnsupportedMediaType, "import takes audio/x-mpegurl or application/xspf+xml")
+			return
+		}
+		options.Format = format
+	}
+
+	parse, ok := playlistFileParsers[options.Format]
+	if !ok {
+		newErrorResponse(c, http.StatusBadRequest, "format must be m3u, m3u8 or xspf")
+		return
+	}
+
+	extendDeadlines(c, importTimeout)
+
+	file, err := parse(http.MaxBytesReader(c.Writer, c.Request.Body, maxPlaylistFileSize))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			newErrorResponse(c, http.StatusRequestEntityTooLarge, fmt.Sprintf("playlist file is larger than %d bytes", tooLarge.Limit))
+			return
+		}
+		logrus.WithError(err).Warn("Invalid playlist file")
+		newErrorResponse(c, http.StatusBadRequest, "invalid playlist file: "+err.Error())
+		return
+	}
+
+	response, err := h.services.PlaylistService.ImportPlaylist(userId, file, options, getAudit(c))
+	if err != nil {
+		serviceErrorResponse(c, err, "Playlist import error")
+		return
+	}
+
+	for i, entry := range response.Entries {
+		if entry.Err == nil {
+			continue
+		}
+		_, description, ok := describeError(entry.Err)
+		if !ok {
+			logrus.WithError(entry.Err).WithField("position", entry.Position).Error("Playlist import entry error")
+		}
+		response.Entries[i].Error, response.Entries[i].Code = description.errorText(), description.Code
+	}
+
+	if response.Playlist == nil {
+		logrus.Info("Playlist import matched no song")
+		c.JSON(http.StatusOK, response)
+		return
+	}
+
+	logrus.Info("Playlist imported successfully")
+	c.JSON(http.StatusCreated, response)
+}
+
+// GetPlaylists godoc
+// @Summary Get playlists
+// @Description Get paginated list of playlists owned by the calling user
+// @Tags playlists
+// @Produce json
+// @Param X-User-ID header string true "User ID"
+// @Param page query int false "Page number" default(1) minimum(1)
+// @Param limit query int false "Items per page" default(10) minimum(1) maximum(100)
+// @Success 200 {object} models.PlaylistsResponse
+// @Failure 400 {object} errorResponse
+// @Failure 401 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /playlists [get]
+func (h *Handler) GetPlaylists(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		return
+	}
+
+	page, limit, err := getPagination(c)
+	if err != nil {
+		return
+	}
+
+	playlists, total, err := h.services.PlaylistService.GetPlaylists(userId, page, limit)
+	if err != nil {
+		serviceErrorResponse(c, err, "Playlists retrieval error")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.PlaylistsResponse{
+		Data:  playlists,
+		Total: total,
+		Page:  page,
+		Limit: limit,
+	})
+}
+
+// GetPlaylistById godoc
+// @Summary Get playlist
+// @Description Get playlist with its songs in order
+// @Tags playlists
+// @Produce json
+// @Param X-User-ID header string true "User ID"
+// @Param id path int true "Playlist ID"
+// @Success 200 {object} models.PlaylistDetail
+// @Failure 400 {object} errorResponse
+// @Failure 401 {object} errorResponse
+// @Failure 403 {object} errorResponse
+// @Failure 404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /playlists/{id} [get]
+func (h *Handler) GetPlaylistById(c *gin.Context) {
+	userId, playlistId, err := getPlaylistParams(c)
+	if err != nil {
+		return
+	}
+
+	playlist, err := h.services.PlaylistService.GetPlaylist(userId, playlistId)
+	if err != nil {
+		serviceErrorResponse(c, err, "Playlist retrieval error")
+		return
+	}
+
+	c.JSON(http.StatusOK, playlist)
+}
+
+// ExportPlaylist godoc
+// @Summary Export playlist
+// @Description Download the songs of a playlist in order as an M3U8, XSPF or PLS playlist file, or in any other
+// @Description export format. M3U8 and PLS files leave out songs without a link.
+// @Tags playlists
+// @Produce audio/x-mpegurl
+// @Produce application/xspf+xml
+// @Produce audio/x-scpls
+// @Param X-User-ID header string true "User ID"
+// @Param id path int true "Playlist ID"
+// @Param format query string false "Export format" Enums(m3u8, xspf, pls, csv, ndjson, json) default(m3u8)
+// @Success 200 {string} string "Playlist file"
+// @Header 200 {string} Content-Disposition "Attachment with the file name of the export"
+// @Failure 400 {object} errorResponse
+// @Failure 401 {object} errorResponse
+// @Failure 403 {object} errorResponse
+// @Failure 404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /playlists/{id}/export [get]
+func (h *Handler) ExportPlaylist(c *gin.Context) {
+	userId, playlistId, err := getPlaylistParams(c)
+	if err != nil {
+		return
+	}
+
+	format, ok := exportFormatParam(c, "m3u8")
+	if !ok {
+		return
+	}
+
+	playlist, err := h.services.PlaylistService.GetPlaylist(userId, playlistId)
+	if err != nil {
+		serviceErrorResponse(c, err, "Playlist retrieval error")
+		return
+	}
+
+	writeSongFile(c, format, fmt.Sprintf("playlist-%d", playlist.ID), playlist.Name, func(each func(models.Song) error) error {
+		for _, entry := range playlist.Entries {
+			if err := each(entry.Song); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// RenamePlaylist godoc
+// @Summary Rename playlist
+// @Description Rename a playlist
+// @Tags playlists
+// @Accept json
+// @Produce json
+// @Param X-User-ID header string true "User ID"
+// @Param id path int true "Playlist ID"
+// @Param input body models.UpdatePlaylistRequest true "Playlist data"
+// @Success 200 {object} statusResponse
+// @Failure 400 {object} errorResponse
+// @Failure 401 {object} errorResponse
+// @Failure 403 {object} errorResponse
+// @Failure 404 {object} errorResponse
+// @Failure 422 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /playlists/{id} [put]
+func (h *Handler) RenamePlaylist(c *gin.Context) {
+	userId, playlistId, err := getPlaylistParams(c)
+	if err != nil {
+		return
+	}
+
+	var input models.UpdatePlaylistRequest
+
+	if err := c.BindJSON(&input); err != nil {
+		logrus.WithError(err).Warn("Invalid request format")
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := h.services.PlaylistService.RenamePlaylist(userId, playlistId, input); err != nil {
+		serviceErrorResponse(c, err, "Playlist rename error")
+		return
+	}
+
+	c.JSON(http.StatusOK, statusResponse{"Playlist renamed successfully"})
+}
+
+// DeletePlaylist godoc
+// @Summary Delete playlist
+// @Description Delete a playlist; the songs themselves are kept
+// @Tags playlists
+// @Produce json
+// @Param X-User-ID header string true "User ID"
+// @Param id path int true "Playlist ID"
+// @Success 200 {object} statusResponse
+// @Failure 400 {object} errorResponse
+// @Failure 401 {object} errorResponse
+// @Failure 403 {object} errorResponse
+// @Failure 404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /playlists/{id} [delete]
+func (h *Handler) DeletePlaylist(c *gin.Context) {
+	userId, playlistId, err := getPlaylistParams(c)
+	if err != nil {
+		return
+	}
+
+	if err := h.services.PlaylistService.DeletePlaylist(userId, playlistId); err != nil {
+		serviceErrorResponse(c, err, "Playlist deletion error")
+		return
+	}
+
+	logrus.Info("Playlist deleted successfully")
+	c.JSON(http.StatusOK, statusResponse{"Playlist deleted successfully"})
+}
+
+// AddPlaylistEntry godoc
+// @Summary Add song to playlist
+// @Description Insert a song at the given position (or at the end) of a playlist
+// @Tags playlists
+// @Accept json
+// @Produce json
+// @Param X-User-ID header string true "User ID"
+// @Param id path int true "Playlist ID"
+// @Param input body models.AddPlaylistEntryRequest true "Entry data"
+// @Success 200 {object} models.PlaylistDetail
+// @Failure 400 {object} errorResponse
+// @Failure 401 {object} errorResponse
+// @Failure 403 {object} errorResponse
+// @Failure 404 {object} errorResponse
+// @Failure 422 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /playlists/{id}/entries [post]
+func (h *Handler) AddPlaylistEntry(c *gin.Context) {
+	userId, playlistId, err := getPlaylistParams(c)
+	if err != nil {
+		return
+	}
+
+	var input models.AddPlaylistEntryRequest
+
+	if err := c.BindJSON(&input); err != nil {
+		logrus.WithError(err).Warn("Invalid request format")
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if input.Position < 0 {
+		newErrorResponse(c, http.StatusBadRequest, "position must be positive")
+		return
+	}
+
+	playlist, err := h.services.PlaylistService.AddPlaylistEntry(userId, playlistId, input)
+	if err != nil {
+		serviceErrorResponse(c, err, "Playlist entry creation error")
+		return
+	}
+
+	c.JSON(http.StatusOK, playlist)
+}
+
+// MovePlaylistEntry godoc
+// @Summary Move playlist entry
+// @Description Move an entry to another position of the playlist
+// @Tags playlists
+// @Accept json
+// @Produce json
+// @Param X-User-ID header string true "User ID"
+// @Param id path int true "Playlist ID"
+// @Param entryId path int true "Entry ID"
+// @Param input body models.MovePlaylistEntryRequest true "New position"
+// @Success 200 {object} models.PlaylistDetail
+// @Failure 400 {object} errorResponse
+// @Failure 401 {object} errorResponse
+// @Failure 403 {object} errorResponse
+// @Failure 404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /playlists/{id}/entries/{entryId} [put]
+func (h *Handler) MovePlaylistEntry(c *gin.Context) {
+	userId, playlistId, err := getPlaylistParams(c)
+	if err != nil {
+		return
+	}
+
+	entryId, err := getIdParam(c, "entryId", "entry")
+	if err != nil {
+		return
+	}
+
+	var input models.MovePlaylistEntryRequest
+
+	if err := c.BindJSON(&input); err != nil {
+		logrus.WithError(err).Warn("Invalid request format")
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if input.Position < 1 {
+		newErrorResponse(c, http.StatusBadRequest, "position must be positive")
+		return
+	}
+
+	playlist, err := h.services.PlaylistService.MovePlaylistEntry(userId, playlistId, entryId, input)
+	if err != nil {
+		serviceErrorResponse(c, err, "Playlist entry move error")
+		return
+	}
+
+	c.JSON(http.StatusOK, playlist)
+}
+
+// RemovePlaylistEntry godoc
+// @Summary Remove playlist entry
+// @Description Remove an entry from a playlist
+// @Tags playlists
+// @Produce json
+// @Param X-User-ID header string true "User ID"
+// @Param id path int true "Playlist ID"
+// @Param entryId path int true "Entry ID"
+// @Success 200 {object} statusResponse
+// @Failure 400 {object} errorResponse
+// @Failure 401 {object} errorResponse
+// @Failure 403 {object} errorResponse
+// @Failure 404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /playlists/{id}/entries/{entryId} [delete]
+func (h *Handler) RemovePlaylistEntry(c *gin.Context) {
+	userId, playlistId, err := getPlaylistParams(c)
+	if err != nil {
+		return
+	}
+
+	entryId, err := getIdParam(c, "entryId", "entry")
+	if err != nil {
+		return
+	}
+
+	if err := h.services.PlaylistService.RemovePlaylistEntry(userId, playlistId, entryId); err != nil {
+		serviceErrorResponse(c, err, "Playlist entry removal error")
+		return
+	}
+
+	c.JSON(http.StatusOK, statusResponse{"Entry removed successfully"})
+}
+
+func getPlaylistParams(c *gin.Context) (string, int, error) {
+	userId, err := getUserId(c)
+	if err != nil {
+		return "", 0, err
+	}
+
+	playlistId, err := getPlaylistId(c)
+	if err != nil {
+		return "", 0, err
+	}
+
+	return userId, playlistId, nil
+}
@@ -1,6 +1,11 @@
 package handler
 
 import (
+	"errors"
+	"net/http"
+
+	"github.com/AntonZatsepilin/music-library.git/internal/models"
+	"github.com/AntonZatsepilin/music-library.git/internal/service"
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
 )
@@ -11,17 +16,112 @@ type errorResponse struct {
 	// Error message
     // Example: invalid request parameters
 	Message string `json:"message"`
+	// Machine-readable error code
+	// Example: not_found
+	Code string `json:"code"`
+	// What exactly was wrong with the request, when known
+	// Example: invalid input: artist name must not be empty
+	Detail string `json:"detail,omitempty"`
+	// Id of the existing song a conflicting request would duplicate
+	ExistingID int `json:"existingId,omitempty"`
 }
 
 // Status response
 // swagger:response statusResponse
 type statusResponse struct {
     // Status message
-    // Example: Song created successfully
+    // Example: Song deleted successfully
 	Status string `json:"status"`
 }
 
+// errorCodes are the machine-readable codes sent with each error status.
+var errorCodes = map[int]string{
+	http.StatusBadRequest:            "bad_request",
+	http.StatusUnauthorized:          "unauthorized",
+	http.StatusForbidden:             "forbidden",
+	http.StatusNotFound:              "not_found",
+	http.StatusConflict:              "conflict",
+	http.StatusPreconditionFailed:    "precondition_failed",
+	http.StatusRequestEntityTooLarge: "payload_too_large",
+	http.StatusUnsupportedMediaType:  "unsupported_media_type",
+	http.StatusUnprocessableEntity:   "validation_failed",
+	http.StatusFailedDependency:      "not_applied",
+	http.StatusInternalServerError:   "internal_error",
+	http.StatusBadGateway:            "upstream_unavailable",
+	http.StatusGatewayTimeout:        "upstream_timeout",
+}
+
+// errorStatuses maps the domain error kinds to response statuses and fixed
+// messages. Upstream kinds come first: a song unknown to the music info API
+// is not found, but an unavailable API is not the client's fault.
+var errorStatuses = []struct {
+	err     error
+	status  int
+	message string
+}{
+	{models.ErrUpstreamTimeout, http.StatusGatewayTimeout, "music info service did not respond in time"},
+	{models.ErrUpstreamUnavailable, http.StatusBadGateway, "music info service is unavailable"},
+	{models.ErrNotFound, http.StatusNotFound, "resource not found"},
+	{models.ErrConflict, http.StatusConflict, "request conflicts with the current state of the resource"},
+	{models.ErrPreconditionFailed, http.StatusPreconditionFailed, "resource has changed since it was read"},
+	{models.ErrForbidden, http.StatusForbidden, "access to the resource is forbidden"},
+	{models.ErrValidation, http.StatusUnprocessableEntity, "invalid input"},
+	{models.ErrInvalidReleaseDate, http.StatusUnprocessableEntity, "invalid release date"},
+	{models.ErrNotApplied, http.StatusFailedDependency, "not applied because another operation of the batch failed"},
+}
+
 func newErrorResponse(c *gin.Context, statusCode int, message string) {
 	logrus.Error(message)
-	c.AbortWithStatusJSON(statusCode, errorResponse{message})
+	c.AbortWithStatusJSON(statusCode, errorResponse{Message: message, Code: errorCodes[statusCode]})
+}
+
+// serviceErrorResponse responds to a failed service call with the status of
+// the error kind. Errors of no known kind are internal; their details are
+// logged but not sent to the client.
+func serviceErrorResponse(c *gin.Context, err error, logMessage string) {
+	if status, response, ok := describeError(err); ok {
+		logrus.WithError(err).Warn(logMessage)
+		c.AbortWithStatusJSON(status, response)
+		return
+	}
+
+	logrus.WithError(err).Error(logMessage)
+	newErrorResponse(c, http.StatusInternalServerError, "internal server error")
+}
+
+// describeError finds the status and response of a service error. The message
+// is fixed by the error kind. Client errors also carry the error the service
+// wrapped around the kind, whose messages are written for clients. Errors
+// from the music info API carry none, as they hold what it sent back, nor do
+// upstream failures, which wrap transport errors; those are only logged. ok
+// is false for errors of no known kind, which are internal server errors.
+func describeError(err error) (status int, response errorResponse, ok bool) {
+	for _, mapping := range errorStatuses {
+		if errors.Is(err, mapping.err) {
+			response = errorResponse{Message: mapping.message, Code: errorCodes[mapping.status]}
+			var upstream *service.APIError
+			if detail := err.Error(); mapping.status < http.StatusInternalServerError && detail != mapping.err.Error() && !errors.As(err, &upstream) {
+				response.Detail = detail
+			}
+
+			var duplicate *models.DuplicateSongError
+			if errors.As(err, &duplicate) {
+				response.ExistingID = duplicate.ExistingID
+			}
+
+			return mapping.status, response, true
+		}
+	}
+
+	status = http.StatusInternalServerError
+	return status, errorResponse{Message: "internal server error", Code: errorCodes[status]}, false
+}
+
+// errorText is the message of a failed item of a bulk request, which has no
+// room for a separate detail.
+func (e errorResponse) errorText() string {
+	if e.Detail != "" {
+		return e.Detail
+	}
+	return e.Message
 }
\ No newline at end of file
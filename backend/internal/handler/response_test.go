@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/AntonZatsepilin/music-library.git/internal/models"
+	"github.com/AntonZatsepilin/music-library.git/internal/service"
+)
+
+func TestDescribeError(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantDetail string
+		wantOK     bool
+	}{
+		{
+			name:       "validation",
+			err:        fmt.Errorf("%w: artist name must not be empty", models.ErrValidation),
+			wantStatus: http.StatusUnprocessableEntity,
+			wantDetail: "invalid input: artist name must not be empty",
+			wantOK:     true,
+		},
+		{
+			name:       "bare kind",
+			err:        models.ErrNotFound,
+			wantStatus: http.StatusNotFound,
+			wantOK:     true,
+		},
+		{
+			name:       "unknown to the music info API",
+			err:        &service.APIError{StatusCode: http.StatusNotFound, Body: `{"trace":"internal"}`},
+			wantStatus: http.StatusNotFound,
+			wantOK:     true,
+		},
+		{
+			name:       "wrapped music info API error",
+			err:        fmt.Errorf("fetching song details: %w", &service.APIError{StatusCode: http.StatusNotFound, Body: "secret"}),
+			wantStatus: http.StatusNotFound,
+			wantOK:     true,
+		},
+		{
+			name:       "music info API failure",
+			err:        &service.APIError{StatusCode: http.StatusInternalServerError, Body: "stack trace"},
+			wantStatus: http.StatusBadGateway,
+			wantOK:     true,
+		},
+		{
+			name:       "unknown kind",
+			err:        fmt.Errorf("connection refused"),
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, response, ok := describeError(tt.err)
+			if status != tt.wantStatus || ok != tt.wantOK {
+				t.Fatalf("describeError(%v) = %d, %v, want %d, %v", tt.err, status, ok, tt.wantStatus, tt.wantOK)
+			}
+			if response.Detail != tt.wantDetail {
+				t.Errorf("describeError(%v) detail = %q, want %q", tt.err, response.Detail, tt.wantDetail)
+			}
+		})
+	}
+}
@@ -0,0 +1,234 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/AntonZatsepilin/music-library.git/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// CreateSmartPlaylist godoc
+// @Summary Create smart playlist
+// @Description Save a named song filter whose songs are evaluated on every read
+// @Tags smart-playlists
+// @Accept json
+// @Produce json
+// @Param X-User-ID header string true "User ID"
+// @Param input body models.SmartPlaylistRequest true "Smart playlist data"
+// @Success 201 {object} models.SmartPlaylist
+// @Failure 400 {object} errorResponse
+// @Failure 401 {object} errorResponse
+// @Failure 422 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /smart-playlists [post]
+func (h *Handler) CreateSmartPlaylist(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		return
+	}
+
+	input, err := bindSmartPlaylistRequest(c)
+	if err != nil {
+		return
+	}
+
+	playlist, err := h.services.SmartPlaylistService.CreateSmartPlaylist(userId, input)
+	if err != nil {
+		serviceErrorResponse(c, err, "Smart playlist creation error")
+		return
+	}
+
+	logrus.Info("Smart playlist created successfully")
+	c.JSON(http.StatusCreated, playlist)
+}
+
+// GetSmartPlaylists godoc
+// @Summary Get smart playlists
+// @Description Get paginated list of smart playlists owned by the calling user
+// @Tags smart-playlists
+// @Produce json
+// @Param X-User-ID header string true "User ID"
+// @Param page query int false "Page number" default(1) minimum(1)
+// @Param limit query int false "Items per page" default(10) minimum(1) maximum(100)
+// @Success 200 {object} models.SmartPlaylistsResponse
+// @Failure 400 {object} errorResponse
+// @Failure 401 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /smart-playlists [get]
+func (h *Handler) GetSmartPlaylists(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		return
+	}
+
+	page, limit, err := getPagination(c)
+	if err != nil {
+		return
+	}
+
+	playlists, total, err := h.services.SmartPlaylistService.GetSmartPlaylists(userId, page, limit)
+	if err != nil {
+		serviceErrorResponse(c, err, "Smart playlists retrieval error")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SmartPlaylistsResponse{
+		Data:  playlists,
+		Total: total,
+		Page:  page,
+		Limit: limit,
+	})
+}
+
+// GetSmartPlaylistById godoc
+// @Summary Get smart playlist
+// @Description Get smart playlist definition
+// @Tags smart-playlists
+// @Produce json
+// @Param X-User-ID header string true "User ID"
+// @Param id path int true "Smart playlist ID"
+// @Success 200 {object} models.SmartPlaylist
+// @Failure 400 {object} errorResponse
+// @Failure 401 {object} errorResponse
+// @Failure 403 {object} errorResponse
+// @Failure 404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /smart-playlists/{id} [get]
+func (h *Handler) GetSmartPlaylistById(c *gin.Context) {
+	userId, playlistId, err := getPlaylistParams(c)
+	if err != nil {
+		return
+	}
+
+	playlist, err := h.services.SmartPlaylistService.GetSmartPlaylist(userId, playlistId)
+	if err != nil {
+		serviceErrorResponse(c, err, "Smart playlist retrieval error")
+		return
+	}
+
+	c.JSON(http.StatusOK, playlist)
+}
+
+// UpdateSmartPlaylist godoc
+// @Summary Update smart playlist
+// @Description Replace name, rules and size of a smart playlist
+// @Tags smart-playlists
+// @Accept json
+// @Produce json
+// @Param X-User-ID header string true "User ID"
+// @Param id path int true "Smart playlist ID"
+// @Param input body models.SmartPlaylistRequest true "Smart playlist data"
+// @Success 200 {object} models.SmartPlaylist
+// @Failure 400 {object} errorResponse
+// @Failure 401 {object} errorResponse
+// @Failure 403 {object} errorResponse
+// @Failure 404 {object} errorResponse
+// @Failure 422 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /smart-playlists/{id} [put]
+func (h *Handler) UpdateSmartPlaylist(c *gin.Context) {
+	userId, playlistId, err := getPlaylistParams(c)
+	if err != nil {
+		return
+	}
+
+	input, err := bindSmartPlaylistRequest(c)
+	if err != nil {
+		return
+	}
+
+	playlist, err := h.services.SmartPlaylistService.UpdateSmartPlaylist(userId, playlistId, input)
+	if err != nil {
+		serviceErrorResponse(c, err, "Smart playlist update error")
+		return
+	}
+
+	c.JSON(http.StatusOK, playlist)
+}
+
+// DeleteSmartPlaylist godoc
+// @Summary Delete smart playlist
+// @Description Delete a smart playlist
+// @Tags smart-playlists
+// @Produce json
+// @Param X-User-ID header string true "User ID"
+// @Param id path int true "Smart playlist ID"
+// @Success 200 {object} statusResponse
+// @Failure 400 {object} errorResponse
+// @Failure 401 {object} errorResponse
+// @Failure 403 {object} errorResponse
+// @Failure 404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /smart-playlists/{id} [delete]
+func (h *Handler) DeleteSmartPlaylist(c *gin.Context) {
+	userId, playlistId, err := getPlaylistParams(c)
+	if err != nil {
+		return
+	}
+
+	if err := h.services.SmartPlaylistService.DeleteSmartPlaylist(userId, playlistId); err != nil {
+		serviceErrorResponse(c, err, "Smart playlist deletion error")
+		return
+	}
+
+	c.JSON(http.StatusOK, statusResponse{"Smart playlist deleted successfully"})
+}
+
+// GetSmartPlaylistSongs godoc
+// @Summary Get smart playlist songs
+// @Description Evaluate the smart playlist rules against the current library
+// @Tags smart-playlists
+// @Produce json
+// @Param X-User-ID header string true "User ID"
+// @Param id path int true "Smart playlist ID"
+// @Param page query int false "Page number" default(1) minimum(1)
+// @Param limit query int false "Items per page" default(10) minimum(1) maximum(100)
+// @Success 200 {object} models.SongsResponse
+// @Failure 400 {object} errorResponse
+// @Failure 401 {object} errorResponse
+// @Failure 403 {object} errorResponse
+// @Failure 404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /smart-playlists/{id}/songs [get]
+func (h *Handler) GetSmartPlaylistSongs(c *gin.Context) {
+	userId, playlistId, err := getPlaylistParams(c)
+	if err != nil {
+		return
+	}
+
+	page, limit, err := getPagination(c)
+	if err != nil {
+		return
+	}
+
+	songs, total, err := h.services.SmartPlaylistService.GetSmartPlaylistSongs(userId, playlistId, page, limit)
+	if err != nil {
+		serviceErrorResponse(c, err, "Smart playlist evaluation error")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SongsResponse{
+		Data:  songs,
+		Total: &total,
+		Page:  page,
+		Limit: limit,
+	})
+}
+
+func bindSmartPlaylistRequest(c *gin.Context) (models.SmartPlaylistRequest, error) {
+	var input models.SmartPlaylistRequest
+
+	if err := c.BindJSON(&input); err != nil {
+		logrus.WithError(err).Warn("Invalid request format")
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return input, err
+	}
+
+	if err := validateSongFilter(input.Rules); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return input, err
+	}
+
+	return input, nil
+}
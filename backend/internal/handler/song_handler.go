@@ -1,6 +1,8 @@
 package handler
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
@@ -10,16 +12,27 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+const mergePatchContentType = "application/merge-patch+json"
+
 // CreateSong godoc
 // @Summary Create new song
-// @Description Create new song with metadata
+// @Description Create new song with metadata from the music info API. Returns the stored song.
+// @Description A song the artist already has, ignoring case and whitespace, is a conflict carrying its existingId.
 // @Tags songs
 // @Accept json
 // @Produce json
+// @Param Idempotency-Key header string false "Key making retries of the request safe; repeated requests get the first response"
 // @Param input body models.CreateSongRequest true "Song data"
-// @Success 200 {object} statusResponse
+// @Success 201 {object} models.Song
+// @Header 201 {string} Location "URL of the created song"
+// @Header 201 {string} ETag "Song version"
 // @Failure 400 {object} errorResponse
+// @Failure 404 {object} errorResponse
+// @Failure 409 {object} errorResponse
+// @Failure 422 {object} errorResponse
 // @Failure 500 {object} errorResponse
+// @Failure 502 {object} errorResponse
+// @Failure 504 {object} errorResponse
 // @Router /songs [post]
 func (h *Handler) CreateSong(c *gin.Context) {
 	logrus.Debug("Received a request to create a song")
@@ -37,26 +50,97 @@ func (h *Handler) CreateSong(c *gin.Context) {
         "song":  inputSong.Song,
     }).Info("An attempt at a song creation")
 
-	if err := h.services.SongService.CreateSong(inputSong); err != nil {
-		logrus.WithError(err).Error("Song creation error")
-		newErrorResponse(c, 500, err.Error())
+	song, err := h.services.SongService.CreateSong(inputSong, getAudit(c))
+	if err != nil {
+		serviceErrorResponse(c, err, "Song creation error")
+		return
+	}
+
+	logrus.WithField("id", song.ID).Info("Song created successfully")
+	c.Header("Location", "/songs/"+strconv.Itoa(song.ID))
+	c.Header("ETag", songETag(song))
+	c.JSON(http.StatusCreated, song)
+}
+
+// ApplySongBatch godoc
+// @Summary Create, update and delete songs in bulk
+// @Description Apply create, update and delete operations in one request. Creates are enriched with the music info
+// @Description API several at a time; updates replace the song like PUT /songs/{id}; a version makes an update or
+// @Description delete conditional. In atomic mode all operations are saved in one transaction or none is, and the
+// @Description operations that did not fail are reported as not_applied (424). In items mode each operation is
+// @Description saved on its own. The response lists the outcome of every operation in request order.
+// @Tags songs
+// @Accept json
+// @Produce json
+// @Param Idempotency-Key header string false "Key making retries of the request safe; repeated requests get the first response"
+// @Param input body models.SongBatchRequest true "Operations"
+// @Success 200 {object} models.SongBatchResponse
+// @Failure 400 {object} errorResponse
+// @Failure 422 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /songs/batch [post]
+func (h *Handler) ApplySongBatch(c *gin.Context) {
+	logrus.Debug("Received a song batch")
+
+	var input models.SongBatchRequest
+
+	if err := c.BindJSON(&input); err != nil {
+		logrus.WithError(err).Warn("Invalid request format")
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	logrus.Info("Song created successfully")
-	c.JSON(200, statusResponse{"Song created successfully"})
+	if input.Mode == "" {
+		input.Mode = models.SongBatchAtomic
+	}
 
+	outcomes, err := h.services.SongService.ApplySongBatch(input, getAudit(c))
+	if err != nil {
+		serviceErrorResponse(c, err, "Song batch error")
+		return
+	}
+
+	response := models.SongBatchResponse{Mode: input.Mode, Results: make([]models.SongBatchResult, len(outcomes))}
+	for i, outcome := range outcomes {
+		result := models.SongBatchResult{Index: i, Op: outcome.Op, ID: outcome.ID, Version: outcome.Version}
+		switch {
+		case outcome.Err != nil:
+			status, description, ok := describeError(outcome.Err)
+			if !ok {
+				logrus.WithError(outcome.Err).WithField("index", i).Error("Song batch operation error")
+			}
+			result.Status, result.Error, result.Code, result.ExistingID =
+				status, description.errorText(), description.Code, description.ExistingID
+			response.Failed++
+		case outcome.Op == "create":
+			result.Status = http.StatusCreated
+			response.Succeeded++
+		default:
+			result.Status = http.StatusOK
+			response.Succeeded++
+		}
+		response.Results[i] = result
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"succeeded": response.Succeeded,
+		"failed":    response.Failed,
+	}).Info("Song batch processed")
+	c.JSON(http.StatusOK, response)
 }
 
 // DeleteSongById godoc
 // @Summary Delete song
-// @Description Delete song by ID
+// @Description Move song to the trash by ID. It can be restored until it is purged after the retention period.
+// @Description With If-Match the song is only deleted at the given version.
 // @Tags songs
 // @Produce json
 // @Param id path int true "Song ID"
+// @Param If-Match header string false "ETag of the song version to delete"
 // @Success 200 {object} statusResponse
 // @Failure 400 {object} errorResponse
 // @Failure 404 {object} errorResponse
+// @Failure 412 {object} errorResponse
 // @Failure 500 {object} errorResponse
 // @Router /songs/{id} [delete]
 func (h *Handler) DeleteSongById(c *gin.Context) {
@@ -68,9 +152,13 @@ func (h *Handler) DeleteSongById(c *gin.Context) {
 		return
 	}
 
-	if err := h.services.SongService.DeleteSongById(songId); err != nil {
-		logrus.WithError(err).Error("Song deletion error")
-		newErrorResponse(c, 500, err.Error())
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		return
+	}
+
+	if err := h.services.SongService.DeleteSongById(songId, version, getAudit(c)); err != nil {
+		serviceErrorResponse(c, err, "Song deletion error")
 		return
 	}
 
@@ -79,16 +167,23 @@ func (h *Handler) DeleteSongById(c *gin.Context) {
 }
 
 // UpdateSongById godoc
-// @Summary Update song
-// @Description Update existing song details
+// @Summary Replace song
+// @Description Replace all editable song details; group and song are required and omitted fields are cleared.
+// @Description Returns the updated song. Use PATCH to change single fields.
+// @Description With If-Match the song is only replaced at the given version.
 // @Tags songs
 // @Accept json
 // @Produce json
 // @Param id path int true "Song ID"
-// @Param input body models.UpdateSongRequest true "Update data"
-// @Success 200 {object} statusResponse
+// @Param If-Match header string false "ETag of the song version to replace"
+// @Param input body models.UpdateSongRequest true "Song data"
+// @Success 200 {object} models.Song
+// @Header 200 {string} ETag "Version of the updated song"
 // @Failure 400 {object} errorResponse
 // @Failure 404 {object} errorResponse
+// @Failure 409 {object} errorResponse
+// @Failure 412 {object} errorResponse
+// @Failure 422 {object} errorResponse
 // @Failure 500 {object} errorResponse
 // @Router /songs/{id} [put]
 func (h *Handler) UpdateSongById(c *gin.Context) {
@@ -100,6 +195,11 @@ func (h *Handler) UpdateSongById(c *gin.Context) {
 		return
 	}
 
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		return
+	}
+
 	var inputSong models.UpdateSongRequest
 
 	if err := c.BindJSON(&inputSong); err != nil {
@@ -113,23 +213,87 @@ func (h *Handler) UpdateSongById(c *gin.Context) {
 		"song":  inputSong.Song,
 	}).Info("An attempt at a song update")
 
-	if err := h.services.SongService.UpdateSongById(songId, inputSong); err != nil {
-		logrus.WithError(err).Error("Song update error")
-		newErrorResponse(c, 500, err.Error())
+	song, err := h.services.SongService.UpdateSongById(songId, inputSong, version, getAudit(c))
+	if err != nil {
+		serviceErrorResponse(c, err, "Song update error")
 		return
 	}
 
 	logrus.Info("Song updated successfully")
-	c.JSON(200, statusResponse{"Song updated successfully"})
+	c.Header("ETag", songETag(song))
+	c.JSON(200, song)
+}
+
+// PatchSongById godoc
+// @Summary Patch song
+// @Description Change song details with a JSON Merge Patch (RFC 7396): absent fields are kept and
+// @Description null clears releaseDate, text or link. Returns the updated song.
+// @Description With If-Match the patch is only applied at the given version.
+// @Tags songs
+// @Accept json
+// @Accept application/merge-patch+json
+// @Produce json
+// @Param id path int true "Song ID"
+// @Param If-Match header string false "ETag of the song version to patch"
+// @Param input body models.PatchSongRequest true "Merge patch"
+// @Success 200 {object} models.Song
+// @Header 200 {string} ETag "Version of the patched song"
+// @Failure 400 {object} errorResponse
+// @Failure 404 {object} errorResponse
+// @Failure 409 {object} errorResponse
+// @Failure 412 {object} errorResponse
+// @Failure 415 {object} errorResponse
+// @Failure 422 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /songs/{id} [patch]
+func (h *Handler) PatchSongById(c *gin.Context) {
+	logrus.Debug("Received a request to patch a song")
+
+	songId, err := getSongId(c)
+	if err != nil {
+		return
+	}
+
+	if contentType := c.ContentType(); contentType != mergePatchContentType && contentType != gin.MIMEJSON {
+		newErrorResponse(c, http.StatusUnsupportedMediaType, "content type must be "+mergePatchContentType)
+		return
+	}
+
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		return
+	}
+
+	var patch models.PatchSongRequest
+
+	if err := c.ShouldBindJSON(&patch); err != nil {
+		logrus.WithError(err).Warn("Invalid request format")
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	song, err := h.services.SongService.PatchSongById(songId, patch, version, getAudit(c))
+	if err != nil {
+		serviceErrorResponse(c, err, "Song patch error")
+		return
+	}
+
+	logrus.Info("Song patched successfully")
+	c.Header("ETag", songETag(song))
+	c.JSON(http.StatusOK, song)
 }
 
 // GetSongById godoc
 // @Summary Get song by ID
-// @Description Get song details by ID
+// @Description Get song details by ID. The ETag header holds the song version; send it back
+// @Description in If-None-Match to get 304 while the song is unchanged, or in If-Match to update it safely.
 // @Tags songs
 // @Produce json
 // @Param id path int true "Song ID"
+// @Param If-None-Match header string false "ETags of cached song versions"
 // @Success 200 {object} models.Song
+// @Header 200 {string} ETag "Song version"
+// @Success 304 "Not Modified"
 // @Failure 400 {object} errorResponse
 // @Failure 404 {object} errorResponse
 // @Failure 500 {object} errorResponse
@@ -146,8 +310,14 @@ func (h *Handler) GetSongById(c *gin.Context) {
 	song, err := h.services.SongService.GetSongById(songId)
 
 	if err != nil {
-		logrus.WithError(err).Error("Song retrieval error")
-		newErrorResponse(c, 500, err.Error())
+		serviceErrorResponse(c, err, "Song retrieval error")
+		return
+	}
+
+	etag := songETag(song)
+	c.Header("ETag", etag)
+	if etagListMatches(c.GetHeader("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
 		return
 	}
 
@@ -191,8 +361,7 @@ func (h *Handler) GetSongLyrics(c *gin.Context) {
 
 	verses, total, err := h.services.SongService.GetSongLyrics(songId, page, limit)
     if err != nil {
-        logrus.WithError(err).Error("Lyrics retrieval error")
-        newErrorResponse(c, http.StatusInternalServerError, err.Error())
+        serviceErrorResponse(c, err, "Lyrics retrieval error")
         return
     }
 
@@ -209,18 +378,33 @@ func (h *Handler) GetSongLyrics(c *gin.Context) {
 
 // GetSongs godoc
 // @Summary Get songs list
-// @Description Get filtered and paginated list of songs
+// @Description Get filtered and paginated list of songs. Pages are selected either by number or,
+// @Description for stable paging through changing data, by passing the returned next_cursor as cursor.
 // @Tags songs
 // @Produce json
 // @Param group query string false "Filter by group name"
+// @Param artistId query int false "Filter by artist ID"
+// @Param albumId query int false "Filter by album ID"
 // @Param song query string false "Filter by song name"
+// @Param match query string false "Group and song name match mode (exact|prefix|fuzzy)" default(exact)
+// @Param threshold query number false "Minimum similarity for match=fuzzy, 0 to 1; defaults to the server setting"
 // @Param releaseDate query string false "Filter by release date (YYYY-MM-DD)"
+// @Param releasedFrom query string false "Released on or after date (YYYY-MM-DD)"
+// @Param releasedTo query string false "Released on or before date (YYYY-MM-DD)"
+// @Param year query int false "Filter by release year"
+// @Param decade query int false "Filter by release decade, e.g. 1990"
 // @Param text query string false "Search in lyrics"
 // @Param link query string false "Filter by link"
-// @Param sort_by query string false "Sort field (group|song|releaseDate|text|link)"
+// @Param genre query []string false "Filter by genre (repeatable)" collectionFormat(multi)
+// @Param genre_match query string false "Genre match mode (any|all)" default(any)
+// @Param tag query []string false "Filter by tag (repeatable)" collectionFormat(multi)
+// @Param tag_match query string false "Tag match mode (any|all)" default(any)
+// @Param sort_by query string false "Sort field (group|song|releaseDate|text|link|score); fuzzy matches default to score"
 // @Param sort_order query string false "Sort order (ASC|DESC)"
 // @Param page query int false "Page number" default(1) minimum(1)
+// @Param cursor query string false "next_cursor of the previous page; replaces page"
 // @Param limit query int false "Items per page" default(10) minimum(1) maximum(100)
+// @Param with_total query bool false "Count the matching songs" default(true)
 // @Success 200 {object} models.SongsResponse
 // @Failure 400 {object} errorResponse
 // @Failure 500 {object} errorResponse
@@ -232,27 +416,9 @@ func (h *Handler) GetSongs(c *gin.Context) {
         return
     }
 
-    if filter.SortBy != "" {
-        allowedFields := map[string]bool{
-            "group":       true,
-            "song":       true,
-            "releaseDate": true,
-            "text":       true,
-            "link":       true,
-            "":           true,
-        }
-        if !allowedFields[filter.SortBy] {
-            newErrorResponse(c, http.StatusBadRequest, "invalid sort_by parameter")
-            return
-        }
-    }
-
-    if filter.SortOrder != "" {
-        order := strings.ToUpper(filter.SortOrder)
-        if order != "ASC" && order != "DESC" && order != "" {
-            newErrorResponse(c, http.StatusBadRequest, "sort_order must be ASC or DESC")
-            return
-        }
+    if err := validateSongFilter(filter); err != nil {
+        newErrorResponse(c, http.StatusBadRequest, err.Error())
+        return
     }
 
     page, err := strconv.Atoi(c.DefaultQuery("page", "1"))	
@@ -267,22 +433,303 @@ func (h *Handler) GetSongs(c *gin.Context) {
         return
     }
 
-    songs, total, err := h.services.SongService.GetSongs(filter, page, limit)
+    withTotal, err := strconv.ParseBool(c.DefaultQuery("with_total", "true"))
+    if err != nil {
+        newErrorResponse(c, http.StatusBadRequest, "with_total must be true or false")
+        return
+    }
+
+    request := models.SongPageRequest{Page: page, Limit: limit, WithTotal: withTotal}
+
+    if value := c.Query("cursor"); value != "" {
+        cursor, err := songCursorParam(c, value, filter)
+        if err != nil {
+            newErrorResponse(c, http.StatusBadRequest, err.Error())
+            return
+        }
+        request.After = &cursor
+    }
+
+    response, err := h.services.SongService.GetSongs(filter, request)
+    if err != nil {
+        serviceErrorResponse(c, err, "Songs retrieval error")
+        return
+    }
+
+    c.JSON(http.StatusOK, response)
+}
+
+
+// SearchSongs godoc
+// @Summary Search songs
+// @Description Full-text search over song names and lyrics, ordered by relevance.
+// @Description Supports "quoted phrases", OR, -excluded words and prefix* terms.
+// @Tags songs
+// @Produce json
+// @Param q query string true "Search query"
+// @Param page query int false "Page number" default(1) minimum(1)
+// @Param limit query int false "Items per page" default(10) minimum(1) maximum(100)
+// @Success 200 {object} models.SearchResponse
+// @Failure 400 {object} errorResponse
+// @Failure 422 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /songs/search [get]
+func (h *Handler) SearchSongs(c *gin.Context) {
+    query := strings.TrimSpace(c.Query("q"))
+    if query == "" {
+        newErrorResponse(c, http.StatusBadRequest, "q must not be empty")
+        return
+    }
+
+    page, limit, err := getPagination(c)
+    if err != nil {
+        return
+    }
+
+    hits, total, err := h.services.SongService.SearchSongs(query, page, limit)
     if err != nil {
-        newErrorResponse(c, http.StatusInternalServerError, "failed to get songs")
+        serviceErrorResponse(c, err, "Song search error")
         return
     }
 
-    response := models.SongsResponse{
-        Data:  songs,
+    c.JSON(http.StatusOK, models.SearchResponse{
+        Data:  hits,
         Total: total,
         Page:  page,
         Limit: limit,
-    }
+    })
+}
 
-    c.JSON(http.StatusOK, response)
+// GetSongDuplicates godoc
+// @Summary Find duplicate songs
+// @Description Report pairs of songs with similar names by the same artist or by artists with similar names,
+// @Description most similar first. Songs of the same artist whose names only differ in case or whitespace
+// @Description cannot be created anymore, but may remain from before.
+// @Tags songs
+// @Produce json
+// @Param threshold query number false "Minimum name similarity, 0 to 1" default(0.6)
+// @Param page query int false "Page number" default(1) minimum(1)
+// @Param limit query int false "Items per page" default(10) minimum(1) maximum(100)
+// @Success 200 {object} models.DuplicatesResponse
+// @Failure 400 {object} errorResponse
+// @Failure 422 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /songs/duplicates [get]
+func (h *Handler) GetSongDuplicates(c *gin.Context) {
+	threshold, err := strconv.ParseFloat(c.DefaultQuery("threshold", "0"), 64)
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "threshold must be a number")
+		return
+	}
+
+	page, limit, err := getPagination(c)
+	if err != nil {
+		return
+	}
+
+	duplicates, hasMore, err := h.services.SongService.GetSongDuplicates(threshold, page, limit)
+	if err != nil {
+		serviceErrorResponse(c, err, "Song duplicates retrieval error")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.DuplicatesResponse{
+		Data:    duplicates,
+		HasMore: hasMore,
+		Page:    page,
+		Limit:   limit,
+	})
 }
 
+// MergeSongs godoc
+// @Summary Merge songs
+// @Description Fold duplicate source songs into the target song in one transaction. Playlist entries, tags and genres
+// @Description move to the target and the sources are deleted. Field values are picked by strategy: target keeps the
+// @Description target's value, earliest takes the earliest release date and longest the longest text or link.
+// @Description With dryRun the merged song is returned without saving anything.
+// @Tags songs
+// @Accept json
+// @Produce json
+// @Param id path int true "Target song ID"
+// @Param input body models.MergeSongsRequest true "Songs to merge"
+// @Success 200 {object} models.MergeSongsResponse
+// @Failure 400 {object} errorResponse
+// @Failure 404 {object} errorResponse
+// @Failure 409 {object} errorResponse
+// @Failure 422 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /songs/{id}/merge [post]
+func (h *Handler) MergeSongs(c *gin.Context) {
+	logrus.Debug("Received a request to merge songs")
+
+	songId, err := getSongId(c)
+	if err != nil {
+		return
+	}
+
+	var input models.MergeSongsRequest
+
+	if err := c.BindJSON(&input); err != nil {
+		logrus.WithError(err).Warn("Invalid request format")
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	result, err := h.services.SongService.MergeSongs(songId, input, getAudit(c))
+	if err != nil {
+		serviceErrorResponse(c, err, "Song merge error")
+		return
+	}
+
+	logrus.WithField("id", songId).Info("Songs merged successfully")
+	c.JSON(http.StatusOK, result)
+}
+
+// GetTrashedSongs godoc
+// @Summary Get deleted songs
+// @Description Get paginated list of songs in the trash, most recently deleted first
+// @Tags songs
+// @Produce json
+// @Param page query int false "Page number" default(1) minimum(1)
+// @Param limit query int false "Items per page" default(10) minimum(1) maximum(100)
+// @Success 200 {object} models.TrashResponse
+// @Failure 400 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /songs/trash [get]
+func (h *Handler) GetTrashedSongs(c *gin.Context) {
+	page, limit, err := getPagination(c)
+	if err != nil {
+		return
+	}
+
+	songs, total, err := h.services.SongService.GetTrashedSongs(page, limit)
+	if err != nil {
+		serviceErrorResponse(c, err, "Trash retrieval error")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.TrashResponse{
+		Data:  songs,
+		Total: total,
+		Page:  page,
+		Limit: limit,
+	})
+}
+
+// RestoreSong godoc
+// @Summary Restore deleted song
+// @Description Take a song out of the trash. Restoring a song the artist has meanwhile recreated is a conflict.
+// @Tags songs
+// @Produce json
+// @Param id path int true "Song ID"
+// @Success 200 {object} models.Song
+// @Failure 400 {object} errorResponse
+// @Failure 404 {object} errorResponse
+// @Failure 409 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /songs/{id}/restore [post]
+func (h *Handler) RestoreSong(c *gin.Context) {
+	logrus.Debug("Received a request to restore a song")
+
+	songId, err := getSongId(c)
+	if err != nil {
+		return
+	}
+
+	song, err := h.services.SongService.RestoreSong(songId, getAudit(c))
+	if err != nil {
+		serviceErrorResponse(c, err, "Song restore error")
+		return
+	}
+
+	logrus.Info("Song restored successfully")
+	c.Header("ETag", songETag(song))
+	c.JSON(http.StatusOK, song)
+}
+
+// GetSongHistory godoc
+// @Summary Get song history
+// @Description Get the recorded changes of a song, newest first. Each revision lists the changed fields with
+// @Description their old and new values, the X-User-ID of the caller and the X-Request-ID of the request.
+// @Tags songs
+// @Produce json
+// @Param id path int true "Song ID"
+// @Param page query int false "Page number" default(1) minimum(1)
+// @Param limit query int false "Items per page" default(10) minimum(1) maximum(100)
+// @Success 200 {object} models.HistoryResponse
+// @Failure 400 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /songs/{id}/history [get]
+func (h *Handler) GetSongHistory(c *gin.Context) {
+	songId, err := getSongId(c)
+	if err != nil {
+		return
+	}
+
+	page, limit, err := getPagination(c)
+	if err != nil {
+		return
+	}
+
+	revisions, total, err := h.services.SongService.GetSongHistory(songId, page, limit)
+	if err != nil {
+		serviceErrorResponse(c, err, "Song history retrieval error")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.HistoryResponse{
+		Data:  revisions,
+		Total: total,
+		Page:  page,
+		Limit: limit,
+	})
+}
+
+// RevertSong godoc
+// @Summary Revert song
+// @Description Bring the group, name, release date, text and link of a song back to an earlier revision.
+// @Description The revert is an update and is recorded as a new revision. With If-Match it only applies at the given version.
+// @Tags songs
+// @Produce json
+// @Param id path int true "Song ID"
+// @Param revision path int true "Revision to revert to"
+// @Param If-Match header string false "ETag of the song version to revert"
+// @Success 200 {object} models.Song
+// @Header 200 {string} ETag "Version of the reverted song"
+// @Failure 400 {object} errorResponse
+// @Failure 404 {object} errorResponse
+// @Failure 409 {object} errorResponse
+// @Failure 412 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /songs/{id}/revert/{revision} [post]
+func (h *Handler) RevertSong(c *gin.Context) {
+	logrus.Debug("Received a request to revert a song")
+
+	songId, err := getSongId(c)
+	if err != nil {
+		return
+	}
+
+	revision, err := getIdParam(c, "revision", "revision")
+	if err != nil {
+		return
+	}
+
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		return
+	}
+
+	song, err := h.services.SongService.RevertSong(songId, revision, version, getAudit(c))
+	if err != nil {
+		serviceErrorResponse(c, err, "Song revert error")
+		return
+	}
+
+	logrus.WithField("revision", revision).Info("Song reverted successfully")
+	c.Header("ETag", songETag(song))
+	c.JSON(http.StatusOK, song)
+}
 
 // GenerateFakeSongs godoc
 // @Summary Generate fake songs
@@ -304,12 +751,144 @@ func (h *Handler) GenerateFakeSongs(c *gin.Context) {
 		return
 	}
 
-	if err := h.services.SongService.GenerateFakeSongs(count); err != nil {
-		logrus.WithError(err).Error("Failed to generate fake songs")
-		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+	if err := h.services.SongService.GenerateFakeSongs(count, getAudit(c)); err != nil {
+		serviceErrorResponse(c, err, "Failed to generate fake songs")
 		return
 	}
 
 	logrus.Info("Fake songs generated successfully")
 	c.JSON(http.StatusOK, statusResponse{"Fake songs generated successfully"})
-}
\ No newline at end of file
+}
+
+// validateSongFilter checks the sort and match parameters of a song filter
+// against the values SongRepository.GetSongs understands.
+func validateSongFilter(filter models.SongFilter) error {
+    if filter.SortBy != "" {
+        allowedFields := map[string]bool{
+            "group":       true,
+            "song":        true,
+            "releaseDate": true,
+            "text":        true,
+            "link":        true,
+            "score":       true,
+            "":            true,
+        }
+        if !allowedFields[filter.SortBy] {
+            return errors.New("invalid sort_by parameter")
+        }
+    }
+
+    switch filter.Match {
+    case "", "exact", "prefix", "fuzzy":
+    default:
+        return errors.New("match must be exact, prefix or fuzzy")
+    }
+
+    if filter.SortBy == "score" && filter.Match != "fuzzy" {
+        return errors.New("sort_by=score requires match=fuzzy")
+    }
+
+    if filter.Threshold < 0 || filter.Threshold > 1 {
+        return errors.New("threshold must be between 0 and 1")
+    }
+
+    if filter.SortOrder != "" {
+        order := strings.ToUpper(filter.SortOrder)
+        if order != "ASC" && order != "DESC" && order != "" {
+            return errors.New("sort_order must be ASC or DESC")
+        }
+    }
+
+    if !isValidMatchMode(filter.GenreMatch) || !isValidMatchMode(filter.TagMatch) {
+        return errors.New("genre_match and tag_match must be any or all")
+    }
+
+    for _, date := range []string{filter.ReleaseDate, filter.ReleasedFrom, filter.ReleasedTo} {
+        if _, err := models.ParseReleaseDate(date); err != nil {
+            return err
+        }
+    }
+
+    if filter.Year < 0 || filter.Year > 9999 {
+        return errors.New("year must be between 1 and 9999")
+    }
+
+    if filter.Decade < 0 || filter.Decade > 9990 || filter.Decade%10 != 0 {
+        return errors.New("decade must be the first year of a decade, e.g. 1990")
+    }
+
+    return nil
+}
+
+// songCursorParam decodes the cursor of a songs request and checks that it
+// was issued for the same order and is not combined with a page number.
+func songCursorParam(c *gin.Context, value string, filter models.SongFilter) (models.SongCursor, error) {
+    if _, ok := c.GetQuery("page"); ok {
+        return models.SongCursor{}, errors.New("cursor cannot be combined with page")
+    }
+
+    if filter.Match == "fuzzy" {
+        return models.SongCursor{}, errors.New("cursor is not supported with match=fuzzy")
+    }
+
+    cursor, err := models.DecodeSongCursor(value)
+    if err != nil {
+        return cursor, err
+    }
+
+    if cursor.SortBy != filter.SortBy || cursor.SortOrder != models.NormalizeSortOrder(filter.SortOrder) {
+        return cursor, errors.New("cursor does not match sort_by and sort_order")
+    }
+
+    return cursor, nil
+}
+
+func isValidMatchMode(mode string) bool {
+    return mode == "" || mode == "any" || mode == "all"
+}
+
+// songETag is the entity tag of a song: its version as a strong ETag.
+func songETag(song models.Song) string {
+	return strconv.Quote(strconv.Itoa(song.Version))
+}
+
+// ifMatchVersion reads the song version a write is conditional on from the
+// If-Match header. It is 0 when the header is absent or "*", meaning any
+// version. Weak or malformed tags never match a song version.
+func ifMatchVersion(c *gin.Context) (int, error) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" || header == "*" {
+		return 0, nil
+	}
+
+	if strings.Contains(header, ",") {
+		err := errors.New("If-Match must hold a single ETag")
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return 0, err
+	}
+
+	value, err := strconv.Unquote(header)
+	var version int
+	if err == nil && strings.HasPrefix(header, `"`) {
+		version, err = strconv.Atoi(value)
+	}
+	if err != nil || version < 1 {
+		err := fmt.Errorf("%w: If-Match %s does not match any song version", models.ErrPreconditionFailed, header)
+		newErrorResponse(c, http.StatusPreconditionFailed, err.Error())
+		return 0, err
+	}
+
+	return version, nil
+}
+
+// etagListMatches reports whether an If-None-Match header matches etag,
+// using the weak comparison RFC 9110 prescribes for it.
+func etagListMatches(header, etag string) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == etag {
+			return true
+		}
+	}
+	return false
+}
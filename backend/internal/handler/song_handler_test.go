@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestIfMatchVersion(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		header     string
+		want       int
+		wantStatus int
+	}{
+		{name: "absent", header: "", want: 0},
+		{name: "any", header: "*", want: 0},
+		{name: "version", header: `"3"`, want: 3},
+		{name: "surrounding spaces", header: ` "12" `, want: 12},
+		{name: "list", header: `"3", "4"`, wantStatus: http.StatusBadRequest},
+		{name: "weak", header: `W/"3"`, wantStatus: http.StatusPreconditionFailed},
+		{name: "unquoted", header: "3", wantStatus: http.StatusPreconditionFailed},
+		{name: "single quotes", header: "'3'", wantStatus: http.StatusPreconditionFailed},
+		{name: "back quotes", header: "`3`", wantStatus: http.StatusPreconditionFailed},
+		{name: "unterminated", header: `"3`, wantStatus: http.StatusPreconditionFailed},
+		{name: "not a number", header: `"abc"`, wantStatus: http.StatusPreconditionFailed},
+		{name: "zero", header: `"0"`, wantStatus: http.StatusPreconditionFailed},
+		{name: "negative", header: `"-1"`, wantStatus: http.StatusPreconditionFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(recorder)
+			c.Request = httptest.NewRequest(http.MethodPut, "/songs/1", nil)
+			if tt.header != "" {
+				c.Request.Header.Set("If-Match", tt.header)
+			}
+
+			got, err := ifMatchVersion(c)
+			if tt.wantStatus != 0 {
+				if err == nil {
+					t.Fatalf("ifMatchVersion(%q) = %d, want an error", tt.header, got)
+				}
+				if recorder.Code != tt.wantStatus {
+					t.Errorf("ifMatchVersion(%q) responded with %d, want %d", tt.header, recorder.Code, tt.wantStatus)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ifMatchVersion(%q) unexpected error: %v", tt.header, err)
+			}
+			if got != tt.want {
+				t.Errorf("ifMatchVersion(%q) = %d, want %d", tt.header, got, tt.want)
+			}
+			if c.Writer.Written() {
+				t.Errorf("ifMatchVersion(%q) wrote a response", tt.header)
+			}
+		})
+	}
+}
+
+func TestEtagListMatches(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		etag   string
+		want   bool
+	}{
+		{name: "same", header: `"3"`, etag: `"3"`, want: true},
+		{name: "weak", header: `W/"3"`, etag: `"3"`, want: true},
+		{name: "in list", header: `"1", W/"2", "3"`, etag: `"3"`, want: true},
+		{name: "list without spaces", header: `"1","3"`, etag: `"3"`, want: true},
+		{name: "any", header: "*", etag: `"3"`, want: true},
+		{name: "surrounding spaces", header: ` "3" `, etag: `"3"`, want: true},
+		{name: "other version", header: `"4"`, etag: `"3"`, want: false},
+		{name: "empty", header: "", etag: `"3"`, want: false},
+		{name: "unquoted", header: "3", etag: `"3"`, want: false},
+		{name: "unterminated", header: `"3`, etag: `"3"`, want: false},
+		{name: "lowercase weak prefix", header: `w/"3"`, etag: `"3"`, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := etagListMatches(tt.header, tt.etag); got != tt.want {
+				t.Errorf("etagListMatches(%q, %q) = %v, want %v", tt.header, tt.etag, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Suggest godoc
+// @Summary Autocomplete names
+// @Description Suggest distinct group or song names for a search box, with the number of songs using each name.
+// @Description Names starting with q come first, followed by names containing q; queries shorter than 3 characters match prefixes only.
+// @Tags search
+// @Produce json
+// @Param q query string true "Typed text"
+// @Param type query string false "Kind of names to suggest (group|song)" default(song)
+// @Param limit query int false "Maximum number of suggestions" default(10) minimum(1) maximum(50)
+// @Success 200 {array} models.Suggestion
+// @Failure 400 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /suggest [get]
+func (h *Handler) Suggest(c *gin.Context) {
+	query := strings.TrimSpace(c.Query("q"))
+	if query == "" {
+		newErrorResponse(c, http.StatusBadRequest, "q must not be empty")
+		return
+	}
+
+	kind := c.DefaultQuery("type", "song")
+	if kind != "group" && kind != "song" {
+		newErrorResponse(c, http.StatusBadRequest, "type must be group or song")
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit < 1 || limit > 50 {
+		newErrorResponse(c, http.StatusBadRequest, "limit must be between 1 and 50")
+		return
+	}
+
+	suggestions, err := h.services.SuggestService.Suggest(kind, query, limit)
+	if err != nil {
+		serviceErrorResponse(c, err, "Suggestions retrieval error")
+		return
+	}
+
+	c.JSON(http.StatusOK, suggestions)
+}
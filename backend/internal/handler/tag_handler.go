@@ -0,0 +1,173 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/AntonZatsepilin/music-library.git/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// AddSongTags godoc
+// @Summary Attach tags to song
+// @Description Attach free-form tags to a song, creating tags that do not exist yet
+// @Tags tags
+// @Accept json
+// @Produce json
+// @Param id path int true "Song ID"
+// @Param input body models.SongTagsRequest true "Tags"
+// @Success 200 {object} statusResponse
+// @Failure 400 {object} errorResponse
+// @Failure 404 {object} errorResponse
+// @Failure 422 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /songs/{id}/tags [post]
+func (h *Handler) AddSongTags(c *gin.Context) {
+	songId, err := getSongId(c)
+	if err != nil {
+		return
+	}
+
+	var input models.SongTagsRequest
+
+	if err := c.BindJSON(&input); err != nil {
+		logrus.WithError(err).Warn("Invalid request format")
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := h.services.TagService.AddSongTags(songId, input.Tags); err != nil {
+		serviceErrorResponse(c, err, "Tags attaching error")
+		return
+	}
+
+	logrus.Info("Tags attached successfully")
+	c.JSON(http.StatusOK, statusResponse{"Tags attached successfully"})
+}
+
+// RemoveSongTag godoc
+// @Summary Detach tag from song
+// @Description Detach a tag from a song
+// @Tags tags
+// @Produce json
+// @Param id path int true "Song ID"
+// @Param tag path string true "Tag name"
+// @Success 200 {object} statusResponse
+// @Failure 400 {object} errorResponse
+// @Failure 404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /songs/{id}/tags/{tag} [delete]
+func (h *Handler) RemoveSongTag(c *gin.Context) {
+	songId, err := getSongId(c)
+	if err != nil {
+		return
+	}
+
+	if err := h.services.TagService.RemoveSongTag(songId, c.Param("tag")); err != nil {
+		serviceErrorResponse(c, err, "Tag detaching error")
+		return
+	}
+
+	logrus.Info("Tag detached successfully")
+	c.JSON(http.StatusOK, statusResponse{"Tag detached successfully"})
+}
+
+// GetTags godoc
+// @Summary Get tags
+// @Description Get all tags with the number of songs using each
+// @Tags tags
+// @Produce json
+// @Success 200 {array} models.TagCount
+// @Failure 500 {object} errorResponse
+// @Router /tags [get]
+func (h *Handler) GetTags(c *gin.Context) {
+	tags, err := h.services.TagService.GetTagCounts()
+	if err != nil {
+		serviceErrorResponse(c, err, "Tags retrieval error")
+		return
+	}
+
+	c.JSON(http.StatusOK, tags)
+}
+
+// AddSongGenres godoc
+// @Summary Attach genres to song
+// @Description Attach genres to a song, creating genres that do not exist yet
+// @Tags genres
+// @Accept json
+// @Produce json
+// @Param id path int true "Song ID"
+// @Param input body models.SongGenresRequest true "Genres"
+// @Success 200 {object} statusResponse
+// @Failure 400 {object} errorResponse
+// @Failure 404 {object} errorResponse
+// @Failure 422 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /songs/{id}/genres [post]
+func (h *Handler) AddSongGenres(c *gin.Context) {
+	songId, err := getSongId(c)
+	if err != nil {
+		return
+	}
+
+	var input models.SongGenresRequest
+
+	if err := c.BindJSON(&input); err != nil {
+		logrus.WithError(err).Warn("Invalid request format")
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := h.services.TagService.AddSongGenres(songId, input.Genres); err != nil {
+		serviceErrorResponse(c, err, "Genres attaching error")
+		return
+	}
+
+	logrus.Info("Genres attached successfully")
+	c.JSON(http.StatusOK, statusResponse{"Genres attached successfully"})
+}
+
+// RemoveSongGenre godoc
+// @Summary Detach genre from song
+// @Description Detach a genre from a song
+// @Tags genres
+// @Produce json
+// @Param id path int true "Song ID"
+// @Param genre path string true "Genre name"
+// @Success 200 {object} statusResponse
+// @Failure 400 {object} errorResponse
+// @Failure 404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /songs/{id}/genres/{genre} [delete]
+func (h *Handler) RemoveSongGenre(c *gin.Context) {
+	songId, err := getSongId(c)
+	if err != nil {
+		return
+	}
+
+	if err := h.services.TagService.RemoveSongGenre(songId, c.Param("genre")); err != nil {
+		serviceErrorResponse(c, err, "Genre detaching error")
+		return
+	}
+
+	logrus.Info("Genre detached successfully")
+	c.JSON(http.StatusOK, statusResponse{"Genre detached successfully"})
+}
+
+// GetGenres godoc
+// @Summary Get genres
+// @Description Get all genres with the number of songs in each
+// @Tags genres
+// @Produce json
+// @Success 200 {array} models.TagCount
+// @Failure 500 {object} errorResponse
+// @Router /genres [get]
+func (h *Handler) GetGenres(c *gin.Context) {
+	genres, err := h.services.TagService.GetGenreCounts()
+	if err != nil {
+		serviceErrorResponse(c, err, "Genres retrieval error")
+		return
+	}
+
+	c.JSON(http.StatusOK, genres)
+}
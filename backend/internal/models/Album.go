@@ -0,0 +1,45 @@
+package models
+
+// Album model
+// swagger:model Album
+type Album struct {
+	ID          int    `db:"id" json:"id"`
+	ArtistID    int    `db:"artist_id" json:"artistId"`
+	Group       string `db:"group_name" json:"group"`
+	Title       string `db:"title" json:"title"`
+	ReleaseYear *int   `db:"release_year" json:"releaseYear"`
+	TrackCount  int    `db:"track_count" json:"trackCount"`
+}
+
+type CreateAlbumRequest struct {
+	Group       string `json:"group" binding:"required"`
+	Title       string `json:"title" binding:"required"`
+	ReleaseYear *int   `json:"releaseYear"`
+}
+
+type UpdateAlbumRequest struct {
+	Group       string `json:"group"`
+	ArtistID    int    `json:"-"`
+	Title       string `json:"title"`
+	ReleaseYear *int   `json:"releaseYear"`
+}
+
+type AlbumFilter struct {
+	ArtistID int    `form:"artistId"`
+	Title    string `form:"title"`
+}
+
+// Position of a song on an album
+type AlbumTrackRequest struct {
+	Disc  int `json:"disc"`
+	Track int `json:"track" binding:"required"`
+}
+
+// Albums response
+// swagger:response albumsResponse
+type AlbumsResponse struct {
+	Data  []Album `json:"data"`
+	Total int     `json:"total"`
+	Page  int     `json:"page"`
+	Limit int     `json:"limit"`
+}
@@ -0,0 +1,39 @@
+package models
+
+import "github.com/lib/pq"
+
+// Artist model
+// swagger:model Artist
+type Artist struct {
+	ID         int            `db:"id" json:"id"`
+	Name       string         `db:"name" json:"name"`
+	SortName   string         `db:"sort_name" json:"sortName"`
+	Country    string         `db:"country" json:"country"`
+	FormedYear *int           `db:"formed_year" json:"formedYear"`
+	Aliases    pq.StringArray `db:"aliases" json:"aliases" swaggertype:"array,string"`
+}
+
+type CreateArtistRequest struct {
+	Name       string   `json:"name" binding:"required"`
+	SortName   string   `json:"sortName"`
+	Country    string   `json:"country"`
+	FormedYear *int     `json:"formedYear"`
+	Aliases    []string `json:"aliases"`
+}
+
+type UpdateArtistRequest struct {
+	Name       string   `json:"name"`
+	SortName   string   `json:"sortName"`
+	Country    string   `json:"country"`
+	FormedYear *int     `json:"formedYear"`
+	Aliases    []string `json:"aliases"`
+}
+
+// Artists response
+// swagger:response artistsResponse
+type ArtistsResponse struct {
+	Data  []Artist `json:"data"`
+	Total int      `json:"total"`
+	Page  int      `json:"page"`
+	Limit int      `json:"limit"`
+}
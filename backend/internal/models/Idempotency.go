@@ -0,0 +1,16 @@
+package models
+
+// IdempotencyRecord is the stored state of an idempotency key.
+type IdempotencyRecord struct {
+	// Hash of the request that first used the key
+	RequestHash string
+	// Response to replay; nil while the first request is still in progress
+	Response *IdempotentResponse
+}
+
+// IdempotentResponse is a response saved for replay to retried requests.
+type IdempotentResponse struct {
+	Status  int
+	Headers map[string]string
+	Body    []byte
+}
@@ -0,0 +1,78 @@
+package models
+
+// Song import formats
+const (
+	ImportCSV    = "csv"
+	ImportNDJSON = "ndjson"
+)
+
+// Options of a song import, read from the query string
+type ImportOptions struct {
+	// csv or ndjson; taken from the Content-Type when empty
+	Format string `form:"format"`
+	// CSV field separator, a single character; a comma when empty
+	Delimiter string `form:"delimiter"`
+	// Whether the first CSV line names the columns
+	Header bool `form:"header,default=true"`
+	// CSV columns of the song fields, by header name or 1-based position.
+	// By default the columns are named after the fields or, without a
+	// header, come in the order group, song, releaseDate, text, link.
+	Group       string `form:"group"`
+	Song        string `form:"song"`
+	ReleaseDate string `form:"releaseDate"`
+	Text        string `form:"text"`
+	Link        string `form:"link"`
+	// Fill in the fields a line leaves empty from the music info API
+	Enrich bool `form:"enrich"`
+	// Check the lines without saving anything
+	DryRun bool `form:"dryRun"`
+}
+
+// Song as read from one line of an import
+type ImportSong struct {
+	Group       string `json:"group"`
+	Song        string `json:"song"`
+	ReleaseDate string `json:"releaseDate"`
+	Text        string `json:"text"`
+	Link        string `json:"link"`
+}
+
+// What became of an import; Failures holds at most the first errors.
+type ImportResult struct {
+	DryRun   bool
+	Lines    int
+	Imported int
+	Failed   int
+	Failures []ImportFailure
+	// First songs a dry run would save
+	Preview []Song
+}
+
+type ImportFailure struct {
+	Line int
+	Err  error
+}
+
+// Error on a line of an import
+// swagger:model ImportLineError
+type ImportLineError struct {
+	Line       int    `json:"line"`
+	Error      string `json:"error"`
+	Code       string `json:"code"`
+	ExistingID int    `json:"existingId,omitempty"`
+}
+
+// Import response
+// swagger:response importResponse
+type ImportResponse struct {
+	DryRun bool `json:"dryRun"`
+	// Lines holding a song, not counting the header and blank lines
+	Lines int `json:"lines"`
+	// Songs saved, or that a dry run would save
+	Imported int `json:"imported"`
+	Failed   int `json:"failed"`
+	// Errors of the first failed lines
+	Errors []ImportLineError `json:"errors"`
+	// First songs a dry run would save, without ids
+	Preview []Song `json:"preview,omitempty"`
+}
@@ -0,0 +1,126 @@
+package models
+
+import "time"
+
+// Playlist model
+// swagger:model Playlist
+type Playlist struct {
+	ID         int       `db:"id" json:"id"`
+	Owner      string    `db:"owner" json:"owner"`
+	Name       string    `db:"name" json:"name"`
+	EntryCount int       `db:"entry_count" json:"entryCount"`
+	CreatedAt  time.Time `db:"created_at" json:"createdAt"`
+	UpdatedAt  time.Time `db:"updated_at" json:"updatedAt"`
+}
+
+// Playlist entry model
+// swagger:model PlaylistEntry
+type PlaylistEntry struct {
+	ID       int  `json:"id"`
+	Position int  `json:"position"`
+	Song     Song `json:"song"`
+}
+
+// Playlist with its entries in order
+// swagger:model PlaylistDetail
+type PlaylistDetail struct {
+	Playlist
+	Entries []PlaylistEntry `json:"entries"`
+}
+
+type CreatePlaylistRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+type UpdatePlaylistRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+type AddPlaylistEntryRequest struct {
+	SongID int `json:"songId" binding:"required"`
+	// Position to insert at, starting from 1; appended to the end when omitted
+	Position int `json:"position"`
+}
+
+type MovePlaylistEntryRequest struct {
+	Position int `json:"position" binding:"required"`
+}
+
+// Playlists response
+// swagger:response playlistsResponse
+type PlaylistsResponse struct {
+	Data  []Playlist `json:"data"`
+	Total int        `json:"total"`
+	Page  int        `json:"page"`
+	Limit int        `json:"limit"`
+}
+
+// Playlist file formats that can be imported
+const (
+	PlaylistFileM3U  = "m3u"
+	PlaylistFileXSPF = "xspf"
+)
+
+// Playlist read from an M3U or XSPF file
+type PlaylistFile struct {
+	Title   string
+	Entries []PlaylistFileEntry
+}
+
+type PlaylistFileEntry struct {
+	Location string
+	Creator  string
+	Title    string
+}
+
+// Options of a playlist import, read from the query string
+type PlaylistImportOptions struct {
+	// m3u, m3u8 or xspf; taken from the Content-Type when empty
+	Format string `form:"format"`
+	// Name of the new playlist; the title in the file by default
+	Name string `form:"name"`
+	// Create the songs that match nothing through the music info API
+	Create bool `form:"create"`
+	// Minimum similarity of a fuzzy match, 0 to 1; defaults to the server setting
+	Threshold float64 `form:"threshold"`
+}
+
+// Playlist import entry statuses
+const (
+	PlaylistEntryMatched   = "matched"
+	PlaylistEntryCreated   = "created"
+	PlaylistEntryUnmatched = "unmatched"
+	PlaylistEntryFailed    = "failed"
+)
+
+// What became of an entry of an imported playlist file
+// swagger:model PlaylistImportEntry
+type PlaylistImportEntry struct {
+	// Position of the entry in the file, from 1
+	Position int    `json:"position"`
+	Location string `json:"location,omitempty"`
+	Creator  string `json:"creator,omitempty"`
+	Title    string `json:"title,omitempty"`
+	Status   string `json:"status" enums:"matched,created,unmatched,failed"`
+	// How the song was matched
+	Match  string `json:"match,omitempty" enums:"link,exact,fuzzy"`
+	SongID int    `json:"songId,omitempty"`
+	// Similarity of a fuzzy match, from 0 to 1
+	Score *float64 `json:"score,omitempty"`
+	// Error message and code of a failed entry
+	Error string `json:"error,omitempty"`
+	Code  string `json:"code,omitempty"`
+	Err   error  `json:"-"`
+}
+
+// Playlist import response
+// swagger:response playlistImportResponse
+type PlaylistImportResponse struct {
+	// The imported playlist; absent when no entry matches a song
+	Playlist  *Playlist             `json:"playlist,omitempty"`
+	Matched   int                   `json:"matched"`
+	Created   int                   `json:"created"`
+	Unmatched int                   `json:"unmatched"`
+	Failed    int                   `json:"failed"`
+	Entries   []PlaylistImportEntry `json:"entries"`
+}
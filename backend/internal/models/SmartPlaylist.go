@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// Smart playlist model: a saved song filter evaluated on every read
+// swagger:model SmartPlaylist
+type SmartPlaylist struct {
+	ID        int        `json:"id"`
+	Owner     string     `json:"owner"`
+	Name      string     `json:"name"`
+	Rules     SongFilter `json:"rules"`
+	MaxSize   int        `json:"maxSize"`
+	CreatedAt time.Time  `json:"createdAt"`
+	UpdatedAt time.Time  `json:"updatedAt"`
+}
+
+type SmartPlaylistRequest struct {
+	Name  string     `json:"name" binding:"required"`
+	Rules SongFilter `json:"rules"`
+	// Maximum number of songs the playlist evaluates to, 100 when omitted
+	MaxSize int `json:"maxSize"`
+}
+
+// Smart playlists response
+// swagger:response smartPlaylistsResponse
+type SmartPlaylistsResponse struct {
+	Data  []SmartPlaylist `json:"data"`
+	Total int             `json:"total"`
+	Page  int             `json:"page"`
+	Limit int             `json:"limit"`
+}
@@ -1,14 +1,30 @@
 package models
 
+import (
+    "time"
+
+    "github.com/lib/pq"
+)
+
 // Song model
 // swagger:model Song
 type Song struct {
-    ID          int    `db:"id" json:"id"`
-    Group       string `db:"group_name" json:"group" binding:"required"`
-    SongName    string `db:"song_name" json:"song" binding:"required"`
-    ReleaseDate string `db:"release_date" json:"releaseDate"`
-    Text        string `db:"text" json:"text"`
-    Link        string `db:"link" json:"link"`
+    ID          int            `db:"id" json:"id"`
+    ArtistID    int            `db:"artist_id" json:"artistId"`
+    Group       string         `db:"group_name" json:"group" binding:"required"`
+    SongName    string         `db:"song_name" json:"song" binding:"required"`
+    ReleaseDate string         `db:"release_date" json:"releaseDate"`
+    Text        string         `db:"text" json:"text"`
+    Link        string         `db:"link" json:"link"`
+    AlbumID     *int           `db:"album_id" json:"albumId"`
+    DiscNumber  *int           `db:"disc_number" json:"disc"`
+    TrackNumber *int           `db:"track_number" json:"track"`
+    Genres      pq.StringArray `db:"genres" json:"genres" swaggertype:"array,string"`
+    Tags        pq.StringArray `db:"tags" json:"tags" swaggertype:"array,string"`
+    Version     int            `db:"version" json:"version"`
+    DeletedAt   *time.Time     `db:"deleted_at" json:"deletedAt,omitempty"`
+    // Similarity to the searched group and song names, set for match=fuzzy only
+    Score *float64 `db:"score" json:"score,omitempty"`
 }
 
 type SongDetail struct {
@@ -22,12 +38,35 @@ type CreateSongRequest struct {
     Song  string `json:"song"`
 }
 
+// Full replacement of a song; omitted optional fields are cleared
 type UpdateSongRequest struct {
-    Group       string `json:"group" db:"group_name"`
-    Song        string `json:"song" db:"song_name"`
-    ReleaseDate string `json:"releaseDate" db:"release_date"`
-    Text        string `json:"text" db:"text"`
-    Link        string `json:"link" db:"link"`
+    Group       string `json:"group" binding:"required"`
+    Song        string `json:"song" binding:"required"`
+    ReleaseDate string `json:"releaseDate"`
+    Text        string `json:"text"`
+    Link        string `json:"link"`
+}
+
+// JSON Merge Patch of a song: absent fields are kept and null clears
+// releaseDate, text and link
+type PatchSongRequest struct {
+    Group       Optional[string] `json:"group" swaggertype:"string"`
+    Song        Optional[string] `json:"song" swaggertype:"string"`
+    ReleaseDate Optional[string] `json:"releaseDate" swaggertype:"string"`
+    Text        Optional[string] `json:"text" swaggertype:"string"`
+    Link        Optional[string] `json:"link" swaggertype:"string"`
+}
+
+// Columns to change in a song update; nil fields are left unchanged and an
+// empty release date clears it
+type SongChanges struct {
+    ArtistID    *int
+    SongName    *string
+    ReleaseDate *string
+    Text        *string
+    Link        *string
+    // Apply the changes only if the song is at this version; 0 for any version
+    Version int
 }
 
 type LyricsRequest struct {
@@ -44,21 +83,118 @@ type LyricResponse struct {
     Limit  int      `json:"limit"`
 }
 
+// Song filter
+// swagger:model SongFilter
 type SongFilter struct {
-    Group       string `form:"group"`
-    Song        string `form:"song"`
-    ReleaseDate string `form:"releaseDate"`
-    Text        string `form:"text"`
-    Link        string `form:"link"`
-    SortBy      string `form:"sort_by"`
-    SortOrder   string `form:"sort_order"`
+    Group        string   `json:"group,omitempty" form:"group"`
+    ArtistID     int      `json:"artistId,omitempty" form:"artistId"`
+    AlbumID      int      `json:"albumId,omitempty" form:"albumId"`
+    Song         string   `json:"song,omitempty" form:"song"`
+    Match        string   `json:"match,omitempty" form:"match"`
+    Threshold    float64  `json:"threshold,omitempty" form:"threshold"`
+    ReleaseDate  string   `json:"releaseDate,omitempty" form:"releaseDate"`
+    ReleasedFrom string   `json:"releasedFrom,omitempty" form:"releasedFrom"`
+    ReleasedTo   string   `json:"releasedTo,omitempty" form:"releasedTo"`
+    Year         int      `json:"year,omitempty" form:"year"`
+    Decade       int      `json:"decade,omitempty" form:"decade"`
+    Text         string   `json:"text,omitempty" form:"text"`
+    Link         string   `json:"link,omitempty" form:"link"`
+    Genres       []string `json:"genres,omitempty" form:"genre"`
+    GenreMatch   string   `json:"genre_match,omitempty" form:"genre_match"`
+    Tags         []string `json:"tags,omitempty" form:"tag"`
+    TagMatch     string   `json:"tag_match,omitempty" form:"tag_match"`
+    SortBy       string   `json:"sort_by,omitempty" form:"sort_by"`
+    SortOrder    string   `json:"sort_order,omitempty" form:"sort_order"`
 }
 
 // Songs response
 // swagger:response songsResponse
 type SongsResponse struct {
+    Data []Song `json:"data"`
+    // Omitted when requested with with_total=false
+    Total *int `json:"total,omitempty"`
+    // Omitted in cursor mode
+    Page  int `json:"page,omitempty"`
+    Limit int `json:"limit"`
+    // Pass as cursor to get the next page; empty on the last page
+    NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// Songs page request: a page number or, in cursor mode, the position after
+// which the page starts
+type SongPageRequest struct {
+    Page      int
+    Limit     int
+    After     *SongCursor
+    WithTotal bool
+}
+
+// Full-text search hit
+// swagger:model SongSearchHit
+type SongSearchHit struct {
+    Song
+    // Relevance of the song for the query, higher is better
+    Rank float64 `db:"rank" json:"rank"`
+    // Matching verse, HTML-escaped, with the matched words wrapped in <b></b>
+    Headline string `db:"headline" json:"headline"`
+}
+
+// Search response
+// swagger:response searchResponse
+type SearchResponse struct {
+    Data  []SongSearchHit `json:"data"`
+    Total int             `json:"total"`
+    Page  int             `json:"page"`
+    Limit int             `json:"limit"`
+}
+
+// Trash response
+// swagger:response trashResponse
+type TrashResponse struct {
     Data  []Song `json:"data"`
     Total int    `json:"total"`
     Page  int    `json:"page"`
     Limit int    `json:"limit"`
-}
\ No newline at end of file
+}
+
+// Pair of songs that probably are the same song
+// swagger:model SongDuplicate
+type SongDuplicate struct {
+    Original  Song `json:"original"`
+    Duplicate Song `json:"duplicate"`
+    // Mean similarity of the group and song names, from 0 to 1
+    Score float64 `json:"score"`
+}
+
+// Duplicates response
+// swagger:response duplicatesResponse
+type DuplicatesResponse struct {
+    Data    []SongDuplicate `json:"data"`
+    // Whether there are more pairs after this page
+    HasMore bool            `json:"hasMore"`
+    Page    int             `json:"page"`
+    Limit   int             `json:"limit"`
+}
+
+// Request to merge source songs into a target song
+type MergeSongsRequest struct {
+    SourceIDs []int         `json:"sourceIds" binding:"required"`
+    Strategy  MergeStrategy `json:"strategy"`
+    // Return the merged song without saving anything
+    DryRun bool `json:"dryRun"`
+}
+
+// How merged songs pick each field value; target keeps the target's value
+type MergeStrategy struct {
+    ReleaseDate string `json:"releaseDate" enums:"target,earliest" default:"target"`
+    Text        string `json:"text" enums:"target,longest" default:"target"`
+    Link        string `json:"link" enums:"target,longest" default:"target"`
+}
+
+// Result of a song merge
+// swagger:model MergeSongsResponse
+type MergeSongsResponse struct {
+    Song      Song  `json:"song"`
+    MergedIDs []int `json:"mergedIds"`
+    DryRun    bool  `json:"dryRun"`
+}
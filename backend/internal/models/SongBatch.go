@@ -0,0 +1,74 @@
+package models
+
+// Song batch modes
+const (
+	SongBatchAtomic = "atomic"
+	SongBatchItems  = "items"
+)
+
+// Operation of a song batch. Create takes group and song and is enriched by
+// the music info API; update replaces the song like PUT /songs/{id}.
+type SongBatchOperation struct {
+	Op string `json:"op" binding:"required" enums:"create,update,delete"`
+	// Song to update or delete
+	ID int `json:"id"`
+	// Update or delete the song only at this version; 0 for any version
+	Version     int    `json:"version"`
+	Group       string `json:"group"`
+	Song        string `json:"song"`
+	ReleaseDate string `json:"releaseDate"`
+	Text        string `json:"text"`
+	Link        string `json:"link"`
+}
+
+// Request to create, update and delete songs in one call
+type SongBatchRequest struct {
+	Operations []SongBatchOperation `json:"operations" binding:"required"`
+	// atomic saves all operations or none; items saves each one that succeeds
+	Mode string `json:"mode" enums:"atomic,items" default:"atomic"`
+}
+
+// Outcome of one batch operation
+// swagger:model SongBatchResult
+type SongBatchResult struct {
+	// Position of the operation in the request
+	Index int    `json:"index"`
+	Op    string `json:"op"`
+	// Status the operation would have had as a single request
+	Status  int `json:"status"`
+	ID      int `json:"id,omitempty"`
+	Version int `json:"version,omitempty"`
+	// Error message and code of a failed operation
+	Error      string `json:"error,omitempty"`
+	Code       string `json:"code,omitempty"`
+	ExistingID int    `json:"existingId,omitempty"`
+}
+
+// Song batch response
+// swagger:response songBatchResponse
+type SongBatchResponse struct {
+	Mode      string            `json:"mode"`
+	Succeeded int               `json:"succeeded"`
+	Failed    int               `json:"failed"`
+	Results   []SongBatchResult `json:"results"`
+}
+
+// Prepared write of a song batch. Creates store Song, updates apply Changes
+// and deletes use Version. Creates and updates name their artist in Group,
+// which is resolved when the write is saved.
+type SongWrite struct {
+	Op      string
+	ID      int
+	Version int
+	Group   string
+	Song    Song
+	Changes SongChanges
+}
+
+// What became of a batch operation; Err is nil when it succeeded.
+type SongBatchOutcome struct {
+	Op      string
+	ID      int
+	Version int
+	Err     error
+}
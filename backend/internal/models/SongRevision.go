@@ -0,0 +1,37 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Recorded change of a song
+// swagger:model SongRevision
+type SongRevision struct {
+	SongID int `json:"songId"`
+	// Song version after the change
+	Revision int `json:"revision"`
+	// create, update, delete, restore or purge
+	Operation string `json:"operation"`
+	// Changed columns with their old and new values
+	Changes   json.RawMessage `json:"changes" swaggertype:"object"`
+	Actor     *string         `json:"actor"`
+	RequestID *string         `json:"requestId"`
+	CreatedAt time.Time       `json:"createdAt"`
+}
+
+// History response
+// swagger:response historyResponse
+type HistoryResponse struct {
+	Data  []SongRevision `json:"data"`
+	Total int            `json:"total"`
+	Page  int            `json:"page"`
+	Limit int            `json:"limit"`
+}
+
+// Audit names who makes a change to a song and in which request, for the
+// song history.
+type Audit struct {
+	Actor     string
+	RequestID string
+}
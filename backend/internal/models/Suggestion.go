@@ -0,0 +1,8 @@
+package models
+
+// Autocomplete suggestion
+// swagger:model Suggestion
+type Suggestion struct {
+	Name  string `db:"name" json:"name"`
+	Count int    `db:"count" json:"count"`
+}
@@ -0,0 +1,16 @@
+package models
+
+// Tag or genre usage count
+// swagger:model TagCount
+type TagCount struct {
+	Name  string `db:"name" json:"name"`
+	Count int    `db:"count" json:"count"`
+}
+
+type SongTagsRequest struct {
+	Tags []string `json:"tags" binding:"required"`
+}
+
+type SongGenresRequest struct {
+	Genres []string `json:"genres" binding:"required"`
+}
@@ -0,0 +1,50 @@
+package models
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// SongCursor is the position of the last song of a page in the songs order.
+// Value holds the sort field of that song; it is nil when sorting by id or
+// when the field is NULL.
+type SongCursor struct {
+	SortBy    string  `json:"s,omitempty"`
+	SortOrder string  `json:"o"`
+	Value     *string `json:"v,omitempty"`
+	ID        int     `json:"i"`
+}
+
+// NormalizeSortOrder returns the sort order songs are listed in for a
+// sort_order parameter: DESC for any spelling of desc and ASC otherwise.
+func NormalizeSortOrder(order string) string {
+	if strings.ToUpper(order) == "DESC" {
+		return "DESC"
+	}
+	return "ASC"
+}
+
+// Encode returns the cursor as an opaque URL-safe string.
+func (c SongCursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeSongCursor parses a cursor produced by SongCursor.Encode.
+func DecodeSongCursor(value string) (SongCursor, error) {
+	var cursor SongCursor
+
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return cursor, ErrInvalidCursor
+	}
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.ID < 1 {
+		return cursor, ErrInvalidCursor
+	}
+
+	return cursor, nil
+}
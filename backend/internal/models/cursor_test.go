@@ -0,0 +1,92 @@
+package models
+
+import (
+	"encoding/base64"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestSongCursorRoundTrip(t *testing.T) {
+	value := func(v string) *string { return &v }
+
+	tests := []struct {
+		name   string
+		cursor SongCursor
+	}{
+		{name: "by id", cursor: SongCursor{SortOrder: "ASC", ID: 1}},
+		{name: "by id descending", cursor: SongCursor{SortOrder: "DESC", ID: 42}},
+		{name: "null value", cursor: SongCursor{SortBy: "releaseDate", SortOrder: "ASC", ID: 7}},
+		{name: "empty value", cursor: SongCursor{SortBy: "text", SortOrder: "DESC", Value: value(""), ID: 7}},
+		{name: "date value", cursor: SongCursor{SortBy: "releaseDate", SortOrder: "ASC", Value: value("2006-07-16"), ID: 3}},
+		{name: "quotes and unicode", cursor: SongCursor{SortBy: "song", SortOrder: "ASC", Value: value(`"Ля-ля" \ ? & =`), ID: 9}},
+		{name: "score", cursor: SongCursor{SortBy: "score", SortOrder: "DESC", Value: value("0.8125"), ID: 2147483647}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoded := tt.cursor.Encode()
+			if _, err := base64.RawURLEncoding.DecodeString(encoded); err != nil {
+				t.Fatalf("Encode() = %q is not URL-safe base64: %v", encoded, err)
+			}
+
+			got, err := DecodeSongCursor(encoded)
+			if err != nil {
+				t.Fatalf("DecodeSongCursor(%q) unexpected error: %v", encoded, err)
+			}
+			if !reflect.DeepEqual(got, tt.cursor) {
+				t.Errorf("DecodeSongCursor(Encode(%+v)) = %+v", tt.cursor, got)
+			}
+		})
+	}
+}
+
+func TestDecodeSongCursorMalformed(t *testing.T) {
+	encode := func(data string) string { return base64.RawURLEncoding.EncodeToString([]byte(data)) }
+
+	tests := []struct {
+		name  string
+		value string
+	}{
+		{name: "empty", value: ""},
+		{name: "not base64", value: "not a cursor!"},
+		{name: "padded base64", value: base64.URLEncoding.EncodeToString([]byte(`{"o":"ASC","i":1}`))},
+		{name: "standard base64", value: base64.RawStdEncoding.EncodeToString([]byte(`{"o":"ASC","v":"??>","i":1}`))},
+		{name: "not json", value: encode("id=1")},
+		{name: "truncated json", value: encode(`{"o":"ASC","i":1`)},
+		{name: "wrong type", value: encode(`{"o":"ASC","i":"1"}`)},
+		{name: "missing id", value: encode(`{"o":"ASC"}`)},
+		{name: "zero id", value: encode(`{"o":"ASC","i":0}`)},
+		{name: "negative id", value: encode(`{"o":"ASC","i":-5}`)},
+		{name: "json array", value: encode(`[1]`)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := DecodeSongCursor(tt.value); !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("DecodeSongCursor(%q) error = %v, want ErrInvalidCursor", tt.value, err)
+			}
+		})
+	}
+}
+
+func TestNormalizeSortOrder(t *testing.T) {
+	tests := []struct {
+		order string
+		want  string
+	}{
+		{order: "", want: "ASC"},
+		{order: "asc", want: "ASC"},
+		{order: "ASC", want: "ASC"},
+		{order: "desc", want: "DESC"},
+		{order: "Desc", want: "DESC"},
+		{order: "DESC", want: "DESC"},
+		{order: "descending", want: "ASC"},
+	}
+
+	for _, tt := range tests {
+		if got := NormalizeSortOrder(tt.order); got != tt.want {
+			t.Errorf("NormalizeSortOrder(%q) = %q, want %q", tt.order, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ReleaseDateLayout is the format release dates are stored and returned in.
+const ReleaseDateLayout = "2006-01-02"
+
+var ErrInvalidReleaseDate = errors.New("invalid release date")
+
+// Layouts accepted for release dates: ISO dates, the day-first dates returned by
+// the music info API ("16.07.2006") and a few other common spellings.
+var releaseDateLayouts = []string{
+	ReleaseDateLayout,
+	"2006-1-2",
+	"2006/1/2",
+	"2.1.2006",
+	"2/1/2006",
+	"2-1-2006",
+	time.RFC3339,
+	"2 January 2006",
+	"2 Jan 2006",
+	"January 2, 2006",
+	"Jan 2, 2006",
+	"January 2006",
+	"2006-01",
+	"2006",
+}
+
+// ParseReleaseDate parses value in any of the accepted layouts and returns it
+// formatted as YYYY-MM-DD. An empty value yields an empty result.
+func ParseReleaseDate(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", nil
+	}
+
+	for _, layout := range releaseDateLayouts {
+		if date, err := time.Parse(layout, value); err == nil {
+			return date.Format(ReleaseDateLayout), nil
+		}
+	}
+
+	return "", fmt.Errorf("%w %q", ErrInvalidReleaseDate, value)
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseReleaseDate(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{name: "empty", value: "", want: ""},
+		{name: "blank", value: "   ", want: ""},
+		{name: "iso", value: "2006-07-16", want: "2006-07-16"},
+		{name: "iso without padding", value: "2006-7-6", want: "2006-07-06"},
+		{name: "slashes", value: "2006/7/16", want: "2006-07-16"},
+		{name: "music info api", value: "16.07.2006", want: "2006-07-16"},
+		{name: "day first slashes", value: "16/07/2006", want: "2006-07-16"},
+		{name: "day first dashes", value: "16-7-2006", want: "2006-07-16"},
+		{name: "rfc 3339", value: "2006-07-16T10:00:00Z", want: "2006-07-16"},
+		{name: "long month", value: "16 July 2006", want: "2006-07-16"},
+		{name: "short month", value: "16 Jul 2006", want: "2006-07-16"},
+		{name: "month first", value: "July 16, 2006", want: "2006-07-16"},
+		{name: "short month first", value: "Jul 16, 2006", want: "2006-07-16"},
+		{name: "month and year", value: "July 2006", want: "2006-07-01"},
+		{name: "iso month", value: "2006-07", want: "2006-07-01"},
+		{name: "year", value: "2006", want: "2006-01-01"},
+		{name: "surrounding spaces", value: " 2006-07-16\t", want: "2006-07-16"},
+		{name: "leap day", value: "29.02.2004", want: "2004-02-29"},
+		{name: "not a leap year", value: "29.02.2005", wantErr: true},
+		{name: "month out of range", value: "2006-13-01", wantErr: true},
+		{name: "day out of range", value: "32.01.2006", wantErr: true},
+		{name: "text", value: "sometime in 2006", wantErr: true},
+		{name: "trailing garbage", value: "2006-07-16x", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseReleaseDate(tt.value)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidReleaseDate) {
+					t.Fatalf("ParseReleaseDate(%q) error = %v, want ErrInvalidReleaseDate", tt.value, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseReleaseDate(%q) unexpected error: %v", tt.value, err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseReleaseDate(%q) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
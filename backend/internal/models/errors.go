@@ -0,0 +1,49 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Kinds of failures the service layer reports. Errors are wrapped around
+// these with fmt.Errorf and %w, and the handlers map each kind to its HTTP
+// status, so new failure paths only need to pick the right kind.
+var (
+	ErrNotFound            = errors.New("not found")
+	ErrConflict            = errors.New("conflict")
+	ErrValidation          = errors.New("invalid input")
+	ErrForbidden           = errors.New("forbidden")
+	ErrPreconditionFailed  = errors.New("precondition failed")
+	ErrUpstreamUnavailable = errors.New("music info service is unavailable")
+	ErrUpstreamTimeout     = errors.New("music info service did not respond in time")
+	ErrNotApplied          = errors.New("not applied because another operation of the batch failed")
+)
+
+// DuplicateSongError reports that the artist already has a song of that name.
+// It is a conflict that carries the id of the existing song.
+type DuplicateSongError struct {
+	ExistingID int
+}
+
+func (e *DuplicateSongError) Error() string {
+	return fmt.Sprintf("%s: song already exists with id %d", ErrConflict, e.ExistingID)
+}
+
+func (e *DuplicateSongError) Unwrap() error {
+	return ErrConflict
+}
+
+// SongWriteError reports that the write at Index of a song batch failed with
+// Err. Errors of the batch as a whole are returned as they are.
+type SongWriteError struct {
+	Index int
+	Err   error
+}
+
+func (e *SongWriteError) Error() string {
+	return fmt.Sprintf("write %d: %v", e.Index, e.Err)
+}
+
+func (e *SongWriteError) Unwrap() error {
+	return e.Err
+}
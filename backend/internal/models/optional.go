@@ -0,0 +1,21 @@
+package models
+
+import "encoding/json"
+
+// Optional is a field of a JSON Merge Patch (RFC 7396) document. A field
+// absent from the document is left unset, null sets Null and any other
+// value is decoded into Value.
+type Optional[T any] struct {
+	Set   bool
+	Null  bool
+	Value T
+}
+
+func (o *Optional[T]) UnmarshalJSON(data []byte) error {
+	o.Set = true
+	if string(data) == "null" {
+		o.Null = true
+		return nil
+	}
+	return json.Unmarshal(data, &o.Value)
+}
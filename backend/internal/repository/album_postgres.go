@@ -0,0 +1,207 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/AntonZatsepilin/music-library.git/internal/models"
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+)
+
+type AlbumPostgres struct {
+	db *sqlx.DB
+}
+
+func NewAlbumPostgres(db *sqlx.DB) *AlbumPostgres {
+	return &AlbumPostgres{db: db}
+}
+
+const (
+	albumColumns = "al.id, al.artist_id, a.name AS group_name, al.title, al.release_year, " +
+		"(SELECT COUNT(*) FROM songs WHERE songs.album_id = al.id AND songs.deleted_at IS NULL) AS track_count"
+	albumsFrom = " FROM albums al JOIN artists a ON a.id = al.artist_id"
+)
+
+func (r *AlbumPostgres) CreateAlbum(album models.Album) (int, error) {
+	logrus.WithFields(logrus.Fields{
+		"artistId": album.ArtistID,
+		"title":    album.Title,
+	}).Debug("Inserting an album into the database")
+
+	query := "INSERT INTO albums (artist_id, title, release_year) VALUES ($1, $2, $3) RETURNING id"
+
+	var id int
+	if err := r.db.QueryRow(query, album.ArtistID, album.Title, album.ReleaseYear).Scan(&id); err != nil {
+		logrus.WithError(err).Error("Error inserting album")
+		return 0, dbError(err)
+	}
+
+	logrus.WithField("id", id).Info("The album has been successfully saved")
+	return id, nil
+}
+
+func (r *AlbumPostgres) GetAlbumById(id int) (models.Album, error) {
+	var album models.Album
+	err := r.db.Get(&album, "SELECT "+albumColumns+albumsFrom+" WHERE al.id = $1", id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return album, fmt.Errorf("album with id %d %w", id, models.ErrNotFound)
+		}
+		return album, err
+	}
+	return album, nil
+}
+
+func (r *AlbumPostgres) GetAlbums(filter models.AlbumFilter, page, limit int) ([]models.Album, int, error) {
+	where := " WHERE 1=1"
+	args := make(map[string]interface{})
+
+	if filter.ArtistID != 0 {
+		where += " AND al.artist_id = :artist_id"
+		args["artist_id"] = filter.ArtistID
+	}
+	if filter.Title != "" {
+		where += " AND al.title ILIKE '%' || :title || '%'"
+		args["title"] = filter.Title
+	}
+
+	countQuery, countArgs, err := sqlx.Named("SELECT COUNT(*)"+albumsFrom+where, args)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var total int
+	if err := r.db.Get(&total, r.db.Rebind(countQuery), countArgs...); err != nil {
+		return nil, 0, err
+	}
+
+	args["limit"] = limit
+	args["offset"] = (page - 1) * limit
+	query, queryArgs, err := sqlx.Named("SELECT "+albumColumns+albumsFrom+where+
+		" ORDER BY a.name, al.release_year NULLS LAST, al.title, al.id LIMIT :limit OFFSET :offset", args)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	albums := []models.Album{}
+	if err := r.db.Select(&albums, r.db.Rebind(query), queryArgs...); err != nil {
+		return nil, 0, err
+	}
+
+	return albums, total, nil
+}
+
+func (r *AlbumPostgres) UpdateAlbumById(id int, input models.UpdateAlbumRequest) error {
+	logrus.WithField("id", id).Debug("Updating an album in the database")
+
+	query := `UPDATE albums SET
+		artist_id = COALESCE(NULLIF($1, 0), artist_id),
+		title = COALESCE(NULLIF($2, ''), title),
+		release_year = COALESCE($3, release_year)
+		WHERE id = $4`
+
+	result, err := r.db.Exec(query, input.ArtistID, input.Title, input.ReleaseYear, id)
+	if err != nil {
+		logrus.WithError(err).Error("Error updating album")
+		return dbError(err)
+	}
+
+	affected, _ := result.RowsAffected()
+	if affected == 0 {
+		return fmt.Errorf("album with id %d %w", id, models.ErrNotFound)
+	}
+
+	logrus.Info("Album successfully updated")
+	return nil
+}
+
+// DeleteAlbumById deletes the album and detaches its tracks; the songs themselves are kept.
+func (r *AlbumPostgres) DeleteAlbumById(id int, audit models.Audit) error {
+	logrus.WithField("id", id).Debug("Deleting an album from the database")
+
+	err := withAudit(r.db, audit, func(tx *sqlx.Tx) error {
+		if _, err := tx.Exec("UPDATE songs SET album_id = NULL, disc_number = NULL, track_number = NULL WHERE album_id = $1", id); err != nil {
+			logrus.WithError(err).Error("Error detaching album tracks")
+			return err
+		}
+
+		result, err := tx.Exec("DELETE FROM albums WHERE id = $1", id)
+		if err != nil {
+			logrus.WithError(err).Error("Database error during album deletion")
+			return err
+		}
+
+		affected, _ := result.RowsAffected()
+		if affected == 0 {
+			return fmt.Errorf("album with id %d %w", id, models.ErrNotFound)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	logrus.Info("Album successfully deleted")
+	return nil
+}
+
+func (r *AlbumPostgres) GetAlbumTracks(id int) ([]models.Song, error) {
+	if _, err := r.GetAlbumById(id); err != nil {
+		return nil, err
+	}
+
+	songs := []models.Song{}
+	query := "SELECT " + songColumns + songsFrom + " WHERE s.album_id = $1 ORDER BY s.disc_number, s.track_number"
+	if err := r.db.Select(&songs, query, id); err != nil {
+		return nil, err
+	}
+
+	return songs, nil
+}
+
+func (r *AlbumPostgres) SetAlbumTrack(albumId, songId, disc, track int, audit models.Audit) error {
+	if _, err := r.GetAlbumById(albumId); err != nil {
+		return err
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"albumId": albumId,
+		"songId":  songId,
+		"disc":    disc,
+		"track":   track,
+	}).Debug("Placing a song on an album")
+
+	query := "UPDATE songs SET album_id = $1, disc_number = $2, track_number = $3 WHERE id = $4 AND deleted_at IS NULL"
+	return withAudit(r.db, audit, func(tx *sqlx.Tx) error {
+		result, err := tx.Exec(query, albumId, disc, track, songId)
+		if err != nil {
+			logrus.WithError(err).Error("Error placing song on album")
+			return dbError(err)
+		}
+
+		affected, _ := result.RowsAffected()
+		if affected == 0 {
+			return fmt.Errorf("song with id %d %w", songId, models.ErrNotFound)
+		}
+		return nil
+	})
+}
+
+func (r *AlbumPostgres) RemoveAlbumTrack(albumId, songId int, audit models.Audit) error {
+	query := "UPDATE songs SET album_id = NULL, disc_number = NULL, track_number = NULL WHERE id = $1 AND album_id = $2"
+	return withAudit(r.db, audit, func(tx *sqlx.Tx) error {
+		result, err := tx.Exec(query, songId, albumId)
+		if err != nil {
+			logrus.WithError(err).Error("Error removing song from album")
+			return err
+		}
+
+		affected, _ := result.RowsAffected()
+		if affected == 0 {
+			return fmt.Errorf("song with id %d on album %d %w", songId, albumId, models.ErrNotFound)
+		}
+		return nil
+	})
+}
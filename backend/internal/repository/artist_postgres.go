@@ -0,0 +1,189 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/AntonZatsepilin/music-library.git/internal/models"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+)
+
+type ArtistPostgres struct {
+	db *sqlx.DB
+}
+
+func NewArtistPostgres(db *sqlx.DB) *ArtistPostgres {
+	return &ArtistPostgres{db: db}
+}
+
+const artistColumns = "artists.id, artists.name, artists.sort_name, artists.country, artists.formed_year, artists.aliases"
+
+func (r *ArtistPostgres) CreateArtist(artist models.Artist) (int, error) {
+	logrus.WithField("name", artist.Name).Debug("Inserting an artist into the database")
+
+	if artist.Aliases == nil {
+		artist.Aliases = pq.StringArray{}
+	}
+
+	query := "INSERT INTO artists (name, sort_name, country, formed_year, aliases) VALUES ($1, $2, $3, $4, $5) RETURNING id"
+
+	var id int
+	err := r.db.QueryRow(query, artist.Name, artist.SortName, artist.Country, artist.FormedYear, artist.Aliases).Scan(&id)
+	if err != nil {
+		logrus.WithError(err).Error("Error inserting artist")
+		return 0, dbError(err)
+	}
+
+	logrus.WithField("id", id).Info("The artist has been successfully saved")
+	return id, nil
+}
+
+func (r *ArtistPostgres) GetArtistById(id int) (models.Artist, error) {
+	var artist models.Artist
+	err := r.db.Get(&artist, "SELECT "+artistColumns+" FROM artists WHERE id = $1", id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return artist, fmt.Errorf("artist with id %d %w", id, models.ErrNotFound)
+		}
+		return artist, err
+	}
+	return artist, nil
+}
+
+func (r *ArtistPostgres) GetArtists(name string, page, limit int) ([]models.Artist, int, error) {
+	where := ""
+	args := []interface{}{}
+	if name != "" {
+		where = " WHERE name ILIKE '%' || $1 || '%' OR EXISTS (SELECT 1 FROM unnest(aliases) AS alias WHERE alias ILIKE '%' || $1 || '%')"
+		args = append(args, name)
+	}
+
+	var total int
+	if err := r.db.Get(&total, "SELECT COUNT(*) FROM artists"+where, args...); err != nil {
+		return nil, 0, err
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM artists%s ORDER BY COALESCE(NULLIF(sort_name, ''), name), id LIMIT $%d OFFSET $%d",
+		artistColumns, where, len(args)+1, len(args)+2)
+	args = append(args, limit, (page-1)*limit)
+
+	artists := []models.Artist{}
+	if err := r.db.Select(&artists, query, args...); err != nil {
+		return nil, 0, err
+	}
+
+	return artists, total, nil
+}
+
+func (r *ArtistPostgres) UpdateArtistById(id int, input models.UpdateArtistRequest) error {
+	logrus.WithField("id", id).Debug("Updating an artist in the database")
+
+	var aliases interface{}
+	if input.Aliases != nil {
+		aliases = pq.Array(input.Aliases)
+	}
+
+	// Songs show the artist name, so renaming an artist moves its songs to
+	// a new version as well.
+	query := `WITH updated AS (
+			UPDATE artists SET
+				name = COALESCE(NULLIF($1, ''), name),
+				sort_name = COALESCE(NULLIF($2, ''), sort_name),
+				country = COALESCE(NULLIF($3, ''), country),
+				formed_year = COALESCE($4, formed_year),
+				aliases = COALESCE($5::text[], aliases)
+			WHERE id = $6
+			RETURNING id
+		), renamed AS (
+			UPDATE songs SET version = version + 1
+			WHERE artist_id IN (SELECT id FROM updated) AND $1 <> ''
+		)
+		SELECT COUNT(*) FROM updated`
+
+	var affected int
+	err := r.db.Get(&affected, query, input.Name, input.SortName, input.Country, input.FormedYear, aliases, id)
+	if err != nil {
+		logrus.WithError(err).Error("Error updating artist")
+		return dbError(err)
+	}
+
+	if affected == 0 {
+		return fmt.Errorf("artist with id %d %w", id, models.ErrNotFound)
+	}
+
+	logrus.Info("Artist successfully updated")
+	return nil
+}
+
+func (r *ArtistPostgres) DeleteArtistById(id int) error {
+	if _, err := r.GetArtistById(id); err != nil {
+		return err
+	}
+
+	var songs int
+	if err := r.db.Get(&songs, "SELECT COUNT(*) FROM songs WHERE artist_id = $1", id); err != nil {
+		return err
+	}
+	if songs > 0 {
+		return fmt.Errorf("%w: artist with id %d still has %d songs", models.ErrConflict, id, songs)
+	}
+
+	var albums int
+	if err := r.db.Get(&albums, "SELECT COUNT(*) FROM albums WHERE artist_id = $1", id); err != nil {
+		return err
+	}
+	if albums > 0 {
+		return fmt.Errorf("%w: artist with id %d still has %d albums", models.ErrConflict, id, albums)
+	}
+
+	logrus.WithField("id", id).Debug("Deleting an artist from the database")
+	if _, err := r.db.Exec("DELETE FROM artists WHERE id = $1", id); err != nil {
+		logrus.WithError(err).Error("Database error during artist deletion")
+		return err
+	}
+
+	logrus.Info("Artist successfully deleted")
+	return nil
+}
+
+// ResolveArtist returns the id of the artist whose name or alias matches name
+// (case- and whitespace-insensitive), creating the artist if none does. The
+// name is expected to be normalized already.
+func (r *ArtistPostgres) ResolveArtist(name string) (int, error) {
+	return resolveArtist(r.db, name)
+}
+
+func resolveArtist(q sqlx.Queryer, name string) (int, error) {
+	query := `WITH found AS (
+			SELECT id FROM artists
+			WHERE artist_name_key(name) = artist_name_key($1)
+				OR artist_alias_keys(aliases) @> ARRAY[artist_name_key($1)]
+			ORDER BY artist_name_key(name) = artist_name_key($1) DESC, id
+			LIMIT 1
+		), inserted AS (
+			INSERT INTO artists (name)
+			SELECT $1 WHERE NOT EXISTS (SELECT 1 FROM found)
+			ON CONFLICT (artist_name_key(name)) DO NOTHING
+			RETURNING id
+		)
+		SELECT id FROM found UNION ALL SELECT id FROM inserted`
+
+	// A concurrent insert of the same name makes ON CONFLICT skip the row
+	// without it being visible to this statement, so try once more.
+	for attempt := 0; attempt < 2; attempt++ {
+		var id int
+		err := sqlx.Get(q, &id, query, name)
+		if err == nil {
+			return id, nil
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			logrus.WithError(err).Error("Error resolving artist")
+			return 0, err
+		}
+	}
+
+	return 0, fmt.Errorf("failed to resolve artist %q", name)
+}
@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/AntonZatsepilin/music-library.git/internal/models"
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+)
+
+type IdempotencyPostgres struct {
+	db *sqlx.DB
+}
+
+func NewIdempotencyPostgres(db *sqlx.DB) *IdempotencyPostgres {
+	return &IdempotencyPostgres{db: db}
+}
+
+type idempotencyRow struct {
+	RequestHash string        `db:"request_hash"`
+	StatusCode  sql.NullInt64 `db:"status_code"`
+	Headers     []byte        `db:"response_headers"`
+	Body        []byte        `db:"response_body"`
+}
+
+// ReserveIdempotencyKey claims the key for a new request identified by token.
+// It returns nil if the key was free, or the record of the earlier request
+// that used it. Keys older than ttl are expired and free again. A request that
+// has not renewed its hold on the key for longer than lease is taken to have
+// died with its process, and the same request may take the key over.
+func (r *IdempotencyPostgres) ReserveIdempotencyKey(scope, key, requestHash, token string, ttl, lease time.Duration) (*models.IdempotencyRecord, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Expired keys are deleted by PurgeIdempotencyKeys; until then they are
+	// overwritten like free ones.
+	query := `INSERT INTO idempotency_keys (scope, key, request_hash, token) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (scope, key) DO UPDATE SET request_hash = EXCLUDED.request_hash, token = EXCLUDED.token, status_code = NULL,
+			response_headers = NULL, response_body = NULL, created_at = now(), locked_at = now()
+		WHERE idempotency_keys.created_at < now() - make_interval(secs => $5)
+			OR (idempotency_keys.status_code IS NULL AND idempotency_keys.request_hash = EXCLUDED.request_hash
+				AND idempotency_keys.locked_at < now() - make_interval(secs => $6))`
+	result, err := tx.Exec(query, scope, key, requestHash, token, ttl.Seconds(), lease.Seconds())
+	if err != nil {
+		logrus.WithError(err).Error("Error reserving idempotency key")
+		return nil, err
+	}
+
+	if affected, _ := result.RowsAffected(); affected == 1 {
+		return nil, tx.Commit()
+	}
+
+	var row idempotencyRow
+	query = "SELECT request_hash, status_code, response_headers, response_body FROM idempotency_keys WHERE scope = $1 AND key = $2"
+	if err := tx.Get(&row, query, scope, key); err != nil {
+		return nil, err
+	}
+
+	record := &models.IdempotencyRecord{RequestHash: row.RequestHash}
+	if row.StatusCode.Valid {
+		record.Response = &models.IdempotentResponse{Status: int(row.StatusCode.Int64), Body: row.Body}
+		if err := json.Unmarshal(row.Headers, &record.Response.Headers); err != nil {
+			return nil, err
+		}
+	}
+
+	return record, tx.Commit()
+}
+
+// SaveIdempotentResponse saves the response of the request holding the key
+// with token. A request whose key was taken over saves nothing.
+func (r *IdempotencyPostgres) SaveIdempotentResponse(scope, key, token string, response models.IdempotentResponse) error {
+	headers, err := json.Marshal(response.Headers)
+	if err != nil {
+		return err
+	}
+
+	query := `UPDATE idempotency_keys SET status_code = $1, response_headers = $2, response_body = $3
+		WHERE scope = $4 AND key = $5 AND token = $6`
+	result, err := r.db.Exec(query, response.Status, string(headers), response.Body, scope, key, token)
+	if err != nil {
+		logrus.WithError(err).Error("Error saving idempotent response")
+		return err
+	}
+
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return fmt.Errorf("%w: idempotency key %q is held by another request", models.ErrConflict, key)
+	}
+	return nil
+}
+
+// RenewIdempotencyKey extends the hold of the request with token on the key
+// while it is still in progress.
+func (r *IdempotencyPostgres) RenewIdempotencyKey(scope, key, token string) error {
+	query := "UPDATE idempotency_keys SET locked_at = now() WHERE scope = $1 AND key = $2 AND token = $3 AND status_code IS NULL"
+	_, err := r.db.Exec(query, scope, key, token)
+	return err
+}
+
+// ReleaseIdempotencyKey frees the key if the request with token still holds it.
+func (r *IdempotencyPostgres) ReleaseIdempotencyKey(scope, key, token string) error {
+	_, err := r.db.Exec("DELETE FROM idempotency_keys WHERE scope = $1 AND key = $2 AND token = $3", scope, key, token)
+	return err
+}
+
+// PurgeIdempotencyKeys deletes the keys older than ttl and returns how many
+// there were.
+func (r *IdempotencyPostgres) PurgeIdempotencyKeys(ttl time.Duration) (int64, error) {
+	result, err := r.db.Exec("DELETE FROM idempotency_keys WHERE created_at < now() - make_interval(secs => $1)", ttl.Seconds())
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,349 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/AntonZatsepilin/music-library.git/internal/models"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+)
+
+type PlaylistPostgres struct {
+	db *sqlx.DB
+}
+
+func NewPlaylistPostgres(db *sqlx.DB) *PlaylistPostgres {
+	return &PlaylistPostgres{db: db}
+}
+
+const playlistColumns = "p.id, p.owner, p.name, p.created_at, p.updated_at, " +
+	"(SELECT COUNT(*) FROM playlist_entries pe JOIN songs s ON s.id = pe.song_id AND s.deleted_at IS NULL WHERE pe.playlist_id = p.id) AS entry_count"
+
+type playlistEntryRow struct {
+	EntryID  int `db:"entry_id"`
+	Position int `db:"position"`
+	models.Song
+}
+
+func (r *PlaylistPostgres) CreatePlaylist(owner, name string) (int, error) {
+	logrus.WithFields(logrus.Fields{
+		"owner": owner,
+		"name":  name,
+	}).Debug("Inserting a playlist into the database")
+
+	var id int
+	err := r.db.QueryRow("INSERT INTO playlists (owner, name) VALUES ($1, $2) RETURNING id", owner, name).Scan(&id)
+	if err != nil {
+		logrus.WithError(err).Error("Error inserting playlist")
+		return 0, dbError(err)
+	}
+
+	logrus.WithField("id", id).Info("The playlist has been successfully saved")
+	return id, nil
+}
+
+// CreatePlaylistWithSongs creates a playlist holding the songs in order.
+func (r *PlaylistPostgres) CreatePlaylistWithSongs(owner, name string, songIds []int) (int, error) {
+	logrus.WithFields(logrus.Fields{
+		"owner": owner,
+		"name":  name,
+		"songs": len(songIds),
+	}).Debug("Inserting a playlist with its songs into the database")
+
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var id int
+	if err := tx.Get(&id, "INSERT INTO playlists (owner, name) VALUES ($1, $2) RETURNING id", owner, name); err != nil {
+		logrus.WithError(err).Error("Error inserting playlist")
+		return 0, dbError(err)
+	}
+
+	query := `INSERT INTO playlist_entries (playlist_id, song_id, position)
+		SELECT $1, e.song_id, e.position FROM unnest($2::int[]) WITH ORDINALITY AS e(song_id, position)`
+	if _, err := tx.Exec(query, id, pq.Array(songIds)); err != nil {
+		logrus.WithError(err).Error("Error inserting playlist entries")
+		return 0, dbError(err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	logrus.WithField("id", id).Info("The playlist has been successfully saved")
+	return id, nil
+}
+
+func (r *PlaylistPostgres) GetPlaylists(owner string, page, limit int) ([]models.Playlist, int, error) {
+	var total int
+	if err := r.db.Get(&total, "SELECT COUNT(*) FROM playlists WHERE owner = $1", owner); err != nil {
+		return nil, 0, err
+	}
+
+	playlists := []models.Playlist{}
+	query := "SELECT " + playlistColumns + " FROM playlists p WHERE p.owner = $1 ORDER BY p.updated_at DESC, p.id DESC LIMIT $2 OFFSET $3"
+	if err := r.db.Select(&playlists, query, owner, limit, (page-1)*limit); err != nil {
+		return nil, 0, err
+	}
+
+	return playlists, total, nil
+}
+
+func (r *PlaylistPostgres) GetPlaylistById(id int) (models.Playlist, error) {
+	var playlist models.Playlist
+	err := r.db.Get(&playlist, "SELECT "+playlistColumns+" FROM playlists p WHERE p.id = $1", id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return playlist, fmt.Errorf("playlist with id %d %w", id, models.ErrNotFound)
+		}
+		return playlist, err
+	}
+	return playlist, nil
+}
+
+func (r *PlaylistPostgres) GetPlaylistEntries(id int) ([]models.PlaylistEntry, error) {
+	query := "SELECT pe.id AS entry_id, pe.position, " + songColumns +
+		" FROM playlist_entries pe JOIN songs s ON s.id = pe.song_id JOIN artists a ON a.id = s.artist_id AND s.deleted_at IS NULL" +
+		" WHERE pe.playlist_id = $1 ORDER BY pe.position"
+
+	var rows []playlistEntryRow
+	if err := r.db.Select(&rows, query, id); err != nil {
+		return nil, err
+	}
+
+	entries := make([]models.PlaylistEntry, 0, len(rows))
+	for i, row := range rows {
+		// Positions may have gaps left by songs in the trash; expose them as 1..n.
+		entries = append(entries, models.PlaylistEntry{
+			ID:       row.EntryID,
+			Position: i + 1,
+			Song:     row.Song,
+		})
+	}
+
+	return entries, nil
+}
+
+func (r *PlaylistPostgres) RenamePlaylist(id int, name string) error {
+	result, err := r.db.Exec("UPDATE playlists SET name = $1, updated_at = now() WHERE id = $2", name, id)
+	if err != nil {
+		logrus.WithError(err).Error("Error renaming playlist")
+		return dbError(err)
+	}
+
+	affected, _ := result.RowsAffected()
+	if affected == 0 {
+		return fmt.Errorf("playlist with id %d %w", id, models.ErrNotFound)
+	}
+
+	return nil
+}
+
+func (r *PlaylistPostgres) DeletePlaylistById(id int) error {
+	result, err := r.db.Exec("DELETE FROM playlists WHERE id = $1", id)
+	if err != nil {
+		logrus.WithError(err).Error("Database error during playlist deletion")
+		return err
+	}
+
+	affected, _ := result.RowsAffected()
+	if affected == 0 {
+		return fmt.Errorf("playlist with id %d %w", id, models.ErrNotFound)
+	}
+
+	logrus.Info("Playlist successfully deleted")
+	return nil
+}
+
+// AddPlaylistEntry inserts the song at position, shifting later entries down.
+// A position outside 1..n+1 appends the song to the end.
+func (r *PlaylistPostgres) AddPlaylistEntry(playlistId, songId, position int) (int, error) {
+	var entryId int
+
+	err := r.editPlaylist(playlistId, func(tx *sqlx.Tx, order playlistOrder) (playlistOrder, error) {
+		var exists bool
+		if err := tx.Get(&exists, "SELECT EXISTS (SELECT 1 FROM songs WHERE id = $1 AND deleted_at IS NULL)", songId); err != nil {
+			return nil, err
+		}
+		if !exists {
+			return nil, fmt.Errorf("song with id %d %w", songId, models.ErrNotFound)
+		}
+
+		// The entry is stored last and put in its place with the others.
+		if err := tx.Get(&entryId, "INSERT INTO playlist_entries (playlist_id, song_id, position) VALUES ($1, $2, $3) RETURNING id",
+			playlistId, songId, len(order)+1); err != nil {
+			return nil, err
+		}
+
+		return order.insert(entryId, position), nil
+	})
+
+	return entryId, err
+}
+
+// MovePlaylistEntry moves the entry to position, shifting the entries in between.
+// A position past the end moves the entry to the end.
+func (r *PlaylistPostgres) MovePlaylistEntry(playlistId, entryId, position int) error {
+	return r.editPlaylist(playlistId, func(tx *sqlx.Tx, order playlistOrder) (playlistOrder, error) {
+		if order.position(entryId) == 0 {
+			return nil, playlistEntryNotFound(playlistId, entryId)
+		}
+		return order.move(entryId, position), nil
+	})
+}
+
+func (r *PlaylistPostgres) RemovePlaylistEntry(playlistId, entryId int) error {
+	return r.editPlaylist(playlistId, func(tx *sqlx.Tx, order playlistOrder) (playlistOrder, error) {
+		if order.position(entryId) == 0 {
+			return nil, playlistEntryNotFound(playlistId, entryId)
+		}
+
+		if _, err := tx.Exec("DELETE FROM playlist_entries WHERE id = $1", entryId); err != nil {
+			return nil, err
+		}
+		return order.remove(entryId), nil
+	})
+}
+
+// editPlaylist runs edit in a transaction holding a row lock on the playlist, so
+// concurrent edits of the same playlist are applied one after another. edit gets
+// the order of the entries and returns the new one, which is stored as positions
+// 1..n.
+func (r *PlaylistPostgres) editPlaylist(playlistId int, edit func(tx *sqlx.Tx, order playlistOrder) (playlistOrder, error)) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var id int
+	if err := tx.Get(&id, "SELECT id FROM playlists WHERE id = $1 FOR UPDATE", playlistId); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("playlist with id %d %w", playlistId, models.ErrNotFound)
+		}
+		return err
+	}
+
+	var order playlistOrder
+	query := `SELECT pe.id, s.deleted_at IS NULL AS live
+		FROM playlist_entries pe JOIN songs s ON s.id = pe.song_id
+		WHERE pe.playlist_id = $1 ORDER BY pe.position, pe.id`
+	if err := tx.Select(&order, query, playlistId); err != nil {
+		return err
+	}
+
+	order, err = edit(tx, order)
+	if err != nil {
+		logrus.WithError(err).WithField("playlistId", playlistId).Error("Error editing playlist")
+		return err
+	}
+
+	ids := make([]int, len(order))
+	for i, slot := range order {
+		ids[i] = slot.ID
+	}
+	renumber := `UPDATE playlist_entries pe SET position = o.position
+		FROM unnest($1::int[]) WITH ORDINALITY AS o(id, position)
+		WHERE pe.id = o.id AND pe.position <> o.position`
+	if _, err := tx.Exec(renumber, pq.Array(ids)); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("UPDATE playlists SET updated_at = now() WHERE id = $1", playlistId); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func playlistEntryNotFound(playlistId, entryId int) error {
+	return fmt.Errorf("entry %d %w in playlist %d", entryId, models.ErrNotFound, playlistId)
+}
+
+// playlistSlot is an entry in the order of a playlist. Entries of songs in the
+// trash keep their place, so they come back there when the song is restored,
+// but clients neither see nor edit them.
+type playlistSlot struct {
+	ID   int  `db:"id"`
+	Live bool `db:"live"`
+}
+
+// playlistOrder is the order of all entries of a playlist. Positions given to
+// its methods count live entries only, from 1, like the entries clients see.
+type playlistOrder []playlistSlot
+
+// position returns the position of the live entry, or 0 when there is none.
+func (o playlistOrder) position(entryId int) int {
+	live := 0
+	for _, slot := range o {
+		if !slot.Live {
+			continue
+		}
+		live++
+		if slot.ID == entryId {
+			return live
+		}
+	}
+	return 0
+}
+
+// live returns the number of live entries.
+func (o playlistOrder) live() int {
+	live := 0
+	for _, slot := range o {
+		if slot.Live {
+			live++
+		}
+	}
+	return live
+}
+
+// move puts the live entry at position, or last for a position past the end.
+// An entry already there keeps its place among the trashed entries too.
+func (o playlistOrder) move(entryId, position int) playlistOrder {
+	if last := o.live(); position > last {
+		position = last
+	}
+	if o.position(entryId) == position {
+		return o
+	}
+	return o.remove(entryId).insert(entryId, position)
+}
+
+// insert puts a live entry at position: before the live entry now there, or
+// after the last live entry for a position outside 1..n.
+func (o playlistOrder) insert(entryId, position int) playlistOrder {
+	index, live := 0, 0
+	for i, slot := range o {
+		if !slot.Live {
+			continue
+		}
+		if live++; live == position {
+			index = i
+			break
+		}
+		index = i + 1
+	}
+
+	inserted := make(playlistOrder, 0, len(o)+1)
+	inserted = append(inserted, o[:index]...)
+	inserted = append(inserted, playlistSlot{ID: entryId, Live: true})
+	return append(inserted, o[index:]...)
+}
+
+// remove leaves the entry out of the order.
+func (o playlistOrder) remove(entryId int) playlistOrder {
+	removed := make(playlistOrder, 0, len(o))
+	for _, slot := range o {
+		if slot.ID != entryId {
+			removed = append(removed, slot)
+		}
+	}
+	return removed
+}
@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPlaylistOrder(t *testing.T) {
+	// Entries 1 and 3 are live; entry 2 is of a song in the trash.
+	order := func() playlistOrder {
+		return playlistOrder{{ID: 1, Live: true}, {ID: 2}, {ID: 3, Live: true}}
+	}
+	ids := func(o playlistOrder) []int {
+		result := make([]int, len(o))
+		for i, slot := range o {
+			result[i] = slot.ID
+		}
+		return result
+	}
+
+	tests := []struct {
+		name string
+		edit func(o playlistOrder) playlistOrder
+		want []int
+	}{
+		{
+			name: "move past a trashed entry",
+			edit: func(o playlistOrder) playlistOrder { return o.move(1, 2) },
+			want: []int{2, 3, 1},
+		},
+		{
+			name: "move to the front",
+			edit: func(o playlistOrder) playlistOrder { return o.move(3, 1) },
+			want: []int{3, 1, 2},
+		},
+		{
+			name: "move to its own position",
+			edit: func(o playlistOrder) playlistOrder { return o.move(3, 2) },
+			want: []int{1, 2, 3},
+		},
+		{
+			name: "move past the end",
+			edit: func(o playlistOrder) playlistOrder { return o.move(1, 10) },
+			want: []int{2, 3, 1},
+		},
+		{
+			name: "insert between live entries",
+			edit: func(o playlistOrder) playlistOrder { return o.insert(4, 2) },
+			want: []int{1, 2, 4, 3},
+		},
+		{
+			name: "insert at the front",
+			edit: func(o playlistOrder) playlistOrder { return o.insert(4, 1) },
+			want: []int{4, 1, 2, 3},
+		},
+		{
+			name: "append after the visible end",
+			edit: func(o playlistOrder) playlistOrder { return o.insert(4, 3) },
+			want: []int{1, 2, 3, 4},
+		},
+		{
+			name: "append without a position",
+			edit: func(o playlistOrder) playlistOrder { return o.insert(4, 0) },
+			want: []int{1, 2, 3, 4},
+		},
+		{
+			name: "move the last entry past the end",
+			edit: func(o playlistOrder) playlistOrder { return o.move(3, 10) },
+			want: []int{1, 2, 3},
+		},
+		{
+			name: "remove",
+			edit: func(o playlistOrder) playlistOrder { return o.remove(1) },
+			want: []int{2, 3},
+		},
+		{
+			name: "insert with only trashed entries",
+			edit: func(o playlistOrder) playlistOrder { return o.remove(1).remove(3).insert(4, 0) },
+			want: []int{4, 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ids(tt.edit(order())); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("entries = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlaylistOrderPosition(t *testing.T) {
+	order := playlistOrder{{ID: 1, Live: true}, {ID: 2}, {ID: 3, Live: true}}
+
+	tests := []struct {
+		entryId int
+		want    int
+	}{
+		{entryId: 1, want: 1},
+		{entryId: 3, want: 2},
+		{entryId: 2, want: 0},
+		{entryId: 4, want: 0},
+	}
+
+	for _, tt := range tests {
+		if got := order.position(tt.entryId); got != tt.want {
+			t.Errorf("position(%d) = %d, want %d", tt.entryId, got, tt.want)
+		}
+	}
+}
@@ -1,9 +1,12 @@
 package repository
 
 import (
+	"errors"
 	"fmt"
 
+	"github.com/AntonZatsepilin/music-library.git/internal/models"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	"github.com/sirupsen/logrus"
 )
 
@@ -31,4 +34,53 @@ func NewPostgresDB(cfg Config) (*sqlx.DB, error) {
 		return nil, err
 	}
 	return db, nil
-}
\ No newline at end of file
+}
+
+// constraintViolations are the domain error kinds of the constraint
+// violations reported by Postgres, with a description safe to show clients.
+var constraintViolations = map[string]struct {
+	kind        error
+	description string
+}{
+	"unique_violation":             {models.ErrConflict, "a record with these values already exists"},
+	"foreign_key_violation":        {models.ErrConflict, "a referenced record does not exist or is still referenced"},
+	"exclusion_violation":          {models.ErrConflict, "the values overlap an existing record"},
+	"check_violation":              {models.ErrValidation, "a value is out of range"},
+	"not_null_violation":           {models.ErrValidation, "a required value is missing"},
+	"string_data_right_truncation": {models.ErrValidation, "a value is too long"},
+}
+
+// constraintError is a constraint violation as a domain error. Its message
+// leaves out the Postgres error, which names tables and constraints; the
+// error is still unwrapped for callers that inspect it.
+type constraintError struct {
+	kind        error
+	description string
+	err         *pq.Error
+}
+
+func (e *constraintError) Error() string {
+	return e.kind.Error() + ": " + e.description
+}
+
+func (e *constraintError) Unwrap() []error {
+	return []error{e.kind, e.err}
+}
+
+// dbError wraps constraint violations reported by Postgres in the matching
+// domain error, so clients get a conflict or validation failure rather than
+// an internal error. Other errors are returned unchanged.
+func dbError(err error) error {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return err
+	}
+
+	violation, ok := constraintViolations[pqErr.Code.Name()]
+	if !ok {
+		return err
+	}
+
+	logrus.WithError(err).WithField("constraint", pqErr.Constraint).Debug("Constraint violation")
+	return &constraintError{kind: violation.kind, description: violation.description, err: pqErr}
+}
@@ -1,25 +1,117 @@
 package repository
 
 import (
+	"context"
+	"time"
+
 	"github.com/AntonZatsepilin/music-library.git/internal/models"
 	"github.com/jmoiron/sqlx"
 )
 
 type  SongRepository interface {
-	CreateSong(song models.Song) error
-	DeleteSongById(id int) error
-	UpdateSongById(id int, input models.UpdateSongRequest) error
+	CreateSong(song models.Song, audit models.Audit) (models.Song, error)
+	DeleteSongById(id, version int, audit models.Audit) error
+	UpdateSongById(id int, changes models.SongChanges, audit models.Audit) (models.Song, error)
 	GetSongById(id int) (models.Song, error)
 	GetSongs(filter models.SongFilter, page, limit int) ([]models.Song, int, error)
+	GetSongsAfter(filter models.SongFilter, after *models.SongCursor, offset, limit int, withTotal bool) ([]models.Song, *int, error)
+	ExportSongs(ctx context.Context, filter models.SongFilter, each func(models.Song) error) error
+	SearchSongs(query string, page, limit int) ([]models.SongSearchHit, int, error)
+	GetSongDuplicates(threshold float64, page, limit int) ([]models.SongDuplicate, bool, error)
+	FindSongId(group, songName string) (int, error)
+	FindSongIdByLink(link string) (int, error)
+	MergeSongs(targetId int, sourceIds []int, merge func(target models.Song, sources []models.Song) models.Song, dryRun bool, audit models.Audit) (models.Song, error)
+	GetTrashedSongs(page, limit int) ([]models.Song, int, error)
+	RestoreSong(id int, audit models.Audit) (models.Song, error)
+	PurgeDeletedSongs(before time.Time) (int64, error)
+	GetSongHistory(songId, page, limit int) ([]models.SongRevision, int, error)
+	GetSongRevision(songId, revision int) (models.Song, error)
+	ApplySongWrites(writes []models.SongWrite, audit models.Audit) ([]models.Song, error)
+}
+
+type ArtistRepository interface {
+	CreateArtist(artist models.Artist) (int, error)
+	GetArtistById(id int) (models.Artist, error)
+	GetArtists(name string, page, limit int) ([]models.Artist, int, error)
+	UpdateArtistById(id int, input models.UpdateArtistRequest) error
+	DeleteArtistById(id int) error
+	ResolveArtist(name string) (int, error)
+}
+
+type AlbumRepository interface {
+	CreateAlbum(album models.Album) (int, error)
+	GetAlbumById(id int) (models.Album, error)
+	GetAlbums(filter models.AlbumFilter, page, limit int) ([]models.Album, int, error)
+	UpdateAlbumById(id int, input models.UpdateAlbumRequest) error
+	DeleteAlbumById(id int, audit models.Audit) error
+	GetAlbumTracks(id int) ([]models.Song, error)
+	SetAlbumTrack(albumId, songId, disc, track int, audit models.Audit) error
+	RemoveAlbumTrack(albumId, songId int, audit models.Audit) error
+}
+
+type TagRepository interface {
+	AddSongTags(songId int, tags []string) error
+	RemoveSongTag(songId int, tag string) error
+	GetTagCounts() ([]models.TagCount, error)
+	AddSongGenres(songId int, genres []string) error
+	RemoveSongGenre(songId int, genre string) error
+	GetGenreCounts() ([]models.TagCount, error)
+}
+
+type PlaylistRepository interface {
+	CreatePlaylist(owner, name string) (int, error)
+	CreatePlaylistWithSongs(owner, name string, songIds []int) (int, error)
+	GetPlaylists(owner string, page, limit int) ([]models.Playlist, int, error)
+	GetPlaylistById(id int) (models.Playlist, error)
+	GetPlaylistEntries(id int) ([]models.PlaylistEntry, error)
+	RenamePlaylist(id int, name string) error
+	DeletePlaylistById(id int) error
+	AddPlaylistEntry(playlistId, songId, position int) (int, error)
+	MovePlaylistEntry(playlistId, entryId, position int) error
+	RemovePlaylistEntry(playlistId, entryId int) error
+}
+
+type SmartPlaylistRepository interface {
+	CreateSmartPlaylist(playlist models.SmartPlaylist) (int, error)
+	GetSmartPlaylists(owner string, page, limit int) ([]models.SmartPlaylist, int, error)
+	GetSmartPlaylistById(id int) (models.SmartPlaylist, error)
+	UpdateSmartPlaylist(playlist models.SmartPlaylist) error
+	DeleteSmartPlaylistById(id int) error
+}
+
+type SuggestRepository interface {
+	Suggest(kind, query string, limit int) ([]models.Suggestion, error)
+	RefreshSuggestions() error
+}
+
+type IdempotencyRepository interface {
+	ReserveIdempotencyKey(scope, key, requestHash, token string, ttl, lease time.Duration) (*models.IdempotencyRecord, error)
+	SaveIdempotentResponse(scope, key, token string, response models.IdempotentResponse) error
+	RenewIdempotencyKey(scope, key, token string) error
+	ReleaseIdempotencyKey(scope, key, token string) error
+	PurgeIdempotencyKeys(ttl time.Duration) (int64, error)
 }
 
 type Repository struct {
 	SongRepository
+	ArtistRepository
+	AlbumRepository
+	TagRepository
+	PlaylistRepository
+	SmartPlaylistRepository
+	SuggestRepository
+	IdempotencyRepository
 }
 
 func NewRepository(db *sqlx.DB) *Repository {
 	return &Repository{
 		SongRepository: NewSongPostgres(db),
-		
+		ArtistRepository: NewArtistPostgres(db),
+		AlbumRepository: NewAlbumPostgres(db),
+		TagRepository: NewTagPostgres(db),
+		PlaylistRepository: NewPlaylistPostgres(db),
+		SmartPlaylistRepository: NewSmartPlaylistPostgres(db),
+		SuggestRepository: NewSuggestPostgres(db),
+		IdempotencyRepository: NewIdempotencyPostgres(db),
 	}
 }
@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/AntonZatsepilin/music-library.git/internal/models"
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+)
+
+type SmartPlaylistPostgres struct {
+	db *sqlx.DB
+}
+
+func NewSmartPlaylistPostgres(db *sqlx.DB) *SmartPlaylistPostgres {
+	return &SmartPlaylistPostgres{db: db}
+}
+
+const smartPlaylistColumns = "id, owner, name, rules, max_size, created_at, updated_at"
+
+type smartPlaylistRow struct {
+	ID        int       `db:"id"`
+	Owner     string    `db:"owner"`
+	Name      string    `db:"name"`
+	Rules     []byte    `db:"rules"`
+	MaxSize   int       `db:"max_size"`
+	CreatedAt time.Time `db:"created_at"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
+
+func (row smartPlaylistRow) toModel() (models.SmartPlaylist, error) {
+	playlist := models.SmartPlaylist{
+		ID:        row.ID,
+		Owner:     row.Owner,
+		Name:      row.Name,
+		MaxSize:   row.MaxSize,
+		CreatedAt: row.CreatedAt,
+		UpdatedAt: row.UpdatedAt,
+	}
+	if err := json.Unmarshal(row.Rules, &playlist.Rules); err != nil {
+		return playlist, fmt.Errorf("invalid rules of smart playlist %d: %w", row.ID, err)
+	}
+	return playlist, nil
+}
+
+func (r *SmartPlaylistPostgres) CreateSmartPlaylist(playlist models.SmartPlaylist) (int, error) {
+	logrus.WithFields(logrus.Fields{
+		"owner": playlist.Owner,
+		"name":  playlist.Name,
+	}).Debug("Inserting a smart playlist into the database")
+
+	rules, err := json.Marshal(playlist.Rules)
+	if err != nil {
+		return 0, err
+	}
+
+	var id int
+	query := "INSERT INTO smart_playlists (owner, name, rules, max_size) VALUES ($1, $2, $3, $4) RETURNING id"
+	if err := r.db.QueryRow(query, playlist.Owner, playlist.Name, string(rules), playlist.MaxSize).Scan(&id); err != nil {
+		logrus.WithError(err).Error("Error inserting smart playlist")
+		return 0, dbError(err)
+	}
+
+	logrus.WithField("id", id).Info("The smart playlist has been successfully saved")
+	return id, nil
+}
+
+func (r *SmartPlaylistPostgres) GetSmartPlaylists(owner string, page, limit int) ([]models.SmartPlaylist, int, error) {
+	var total int
+	if err := r.db.Get(&total, "SELECT COUNT(*) FROM smart_playlists WHERE owner = $1", owner); err != nil {
+		return nil, 0, err
+	}
+
+	var rows []smartPlaylistRow
+	query := "SELECT " + smartPlaylistColumns + " FROM smart_playlists WHERE owner = $1 ORDER BY updated_at DESC, id DESC LIMIT $2 OFFSET $3"
+	if err := r.db.Select(&rows, query, owner, limit, (page-1)*limit); err != nil {
+		return nil, 0, err
+	}
+
+	playlists := make([]models.SmartPlaylist, 0, len(rows))
+	for _, row := range rows {
+		playlist, err := row.toModel()
+		if err != nil {
+			return nil, 0, err
+		}
+		playlists = append(playlists, playlist)
+	}
+
+	return playlists, total, nil
+}
+
+func (r *SmartPlaylistPostgres) GetSmartPlaylistById(id int) (models.SmartPlaylist, error) {
+	var row smartPlaylistRow
+	err := r.db.Get(&row, "SELECT "+smartPlaylistColumns+" FROM smart_playlists WHERE id = $1", id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.SmartPlaylist{}, fmt.Errorf("smart playlist with id %d %w", id, models.ErrNotFound)
+		}
+		return models.SmartPlaylist{}, err
+	}
+	return row.toModel()
+}
+
+func (r *SmartPlaylistPostgres) UpdateSmartPlaylist(playlist models.SmartPlaylist) error {
+	rules, err := json.Marshal(playlist.Rules)
+	if err != nil {
+		return err
+	}
+
+	query := "UPDATE smart_playlists SET name = $1, rules = $2, max_size = $3, updated_at = now() WHERE id = $4"
+	result, err := r.db.Exec(query, playlist.Name, string(rules), playlist.MaxSize, playlist.ID)
+	if err != nil {
+		logrus.WithError(err).Error("Error updating smart playlist")
+		return dbError(err)
+	}
+
+	affected, _ := result.RowsAffected()
+	if affected == 0 {
+		return fmt.Errorf("smart playlist with id %d %w", playlist.ID, models.ErrNotFound)
+	}
+
+	return nil
+}
+
+func (r *SmartPlaylistPostgres) DeleteSmartPlaylistById(id int) error {
+	result, err := r.db.Exec("DELETE FROM smart_playlists WHERE id = $1", id)
+	if err != nil {
+		logrus.WithError(err).Error("Database error during smart playlist deletion")
+		return err
+	}
+
+	affected, _ := result.RowsAffected()
+	if affected == 0 {
+		return fmt.Errorf("smart playlist with id %d %w", id, models.ErrNotFound)
+	}
+
+	return nil
+}
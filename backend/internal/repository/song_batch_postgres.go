@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"fmt"
+
+	"github.com/AntonZatsepilin/music-library.git/internal/models"
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+)
+
+// ApplySongWrites saves the writes in order in a single transaction, artists
+// they name included, and returns the stored songs, deleted ones with only
+// their id set. On failure nothing is saved. A write that fails is reported
+// as a *models.SongWriteError; any other error fails the batch as a whole.
+func (r *SongPostgres) ApplySongWrites(writes []models.SongWrite, audit models.Audit) ([]models.Song, error) {
+	logrus.WithField("count", len(writes)).Debug("Applying a song batch")
+
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := setAudit(tx, audit); err != nil {
+		return nil, err
+	}
+
+	songs := make([]models.Song, 0, len(writes))
+	for i, write := range writes {
+		song, err := applySongWrite(tx, i, write)
+		if err != nil {
+			return nil, err
+		}
+		songs = append(songs, song)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	logrus.WithField("count", len(songs)).Info("Song batch successfully applied")
+	return songs, nil
+}
+
+func applySongWrite(tx *sqlx.Tx, index int, write models.SongWrite) (models.Song, error) {
+	writeError := func(err error) error {
+		return &models.SongWriteError{Index: index, Err: err}
+	}
+
+	if write.Group != "" {
+		artistId, err := resolveArtist(tx, write.Group)
+		if err != nil {
+			return models.Song{}, err
+		}
+		write.Song.ArtistID, write.Changes.ArtistID = artistId, &artistId
+	}
+
+	switch write.Op {
+	case "create":
+		var song models.Song
+		err := savepointed(tx, func() (err error) {
+			song, err = insertSong(tx, write.Song)
+			return err
+		}, func(err error) error {
+			return writeError(duplicateSongError(tx, err, 0, &write.Song.ArtistID, &write.Song.SongName))
+		})
+		return song, err
+	case "update":
+		query, args := updateSongQuery(write.ID, write.Changes)
+		if query == "" {
+			return models.Song{}, writeError(fmt.Errorf("%w: update of song %d changes nothing", models.ErrValidation, write.ID))
+		}
+		var song models.Song
+		err := savepointed(tx, func() error {
+			return tx.Get(&song, query, args...)
+		}, func(err error) error {
+			return writeError(updateSongError(tx, err, write.ID, write.Changes))
+		})
+		return song, err
+	case "delete":
+		affected, err := trashSong(tx, write.ID, write.Version)
+		if err != nil {
+			return models.Song{}, err
+		}
+		if affected == 0 {
+			return models.Song{}, writeError(missingSongError(tx, write.ID))
+		}
+		return models.Song{ID: write.ID}, nil
+	}
+	return models.Song{}, writeError(fmt.Errorf("%w: unknown operation %q", models.ErrValidation, write.Op))
+}
+
+// savepointed runs write behind a savepoint. A failed statement aborts the
+// transaction, so on failure it goes back to the savepoint before explain
+// turns the error into the one returned, looking up what it needs in the
+// transaction.
+func savepointed(tx *sqlx.Tx, write func() error, explain func(err error) error) error {
+	if _, err := tx.Exec("SAVEPOINT song_write"); err != nil {
+		return err
+	}
+
+	err := write()
+	if err == nil {
+		return nil
+	}
+
+	if _, rollbackErr := tx.Exec("ROLLBACK TO SAVEPOINT song_write"); rollbackErr != nil {
+		return rollbackErr
+	}
+	return explain(err)
+}
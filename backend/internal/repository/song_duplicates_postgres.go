@@ -0,0 +1,257 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/AntonZatsepilin/music-library.git/internal/models"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+)
+
+// songNameIndex is the unique index on the artist and normalized song name.
+const songNameIndex = "songs_name_key"
+
+type duplicatePair struct {
+	OriginalID  int     `db:"original_id"`
+	DuplicateID int     `db:"duplicate_id"`
+	Score       float64 `db:"score"`
+}
+
+// GetSongDuplicates finds pairs of songs whose names are at least threshold
+// similar, by the same artist or by artists with similar names. Pairs are
+// ordered from the most similar. Counting all pairs would mean finding them
+// all, so only whether there are more after the page is reported.
+func (r *SongPostgres) GetSongDuplicates(threshold float64, page, limit int) ([]models.SongDuplicate, bool, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return nil, false, err
+	}
+	defer tx.Rollback()
+
+	// The % operator uses the session threshold, which lets it use the
+	// trigram index on song names.
+	if _, err := tx.Exec("SELECT set_config('pg_trgm.similarity_threshold', $1, true)",
+		strconv.FormatFloat(threshold, 'f', -1, 64)); err != nil {
+		return nil, false, err
+	}
+
+	pairs := `SELECT s1.id AS original_id, s2.id AS duplicate_id,
+			CAST((similarity(a1.name, a2.name) + similarity(s1.song_name, s2.song_name)) / 2 AS float8) AS score
+		FROM songs s1
+		JOIN artists a1 ON a1.id = s1.artist_id
+		JOIN songs s2 ON s2.id > s1.id AND s2.song_name % s1.song_name
+		JOIN artists a2 ON a2.id = s2.artist_id
+		WHERE s1.deleted_at IS NULL AND s2.deleted_at IS NULL
+			AND (s1.artist_id = s2.artist_id OR a1.name % a2.name)`
+
+	var found []duplicatePair
+	if err := tx.Select(&found, pairs+" ORDER BY score DESC, s1.id, s2.id LIMIT $1 OFFSET $2", limit+1, (page-1)*limit); err != nil {
+		return nil, false, err
+	}
+
+	hasMore := len(found) > limit
+	if hasMore {
+		found = found[:limit]
+	}
+
+	ids := make([]int64, 0, 2*len(found))
+	for _, pair := range found {
+		ids = append(ids, int64(pair.OriginalID), int64(pair.DuplicateID))
+	}
+
+	var songs []models.Song
+	if err := tx.Select(&songs, "SELECT "+songColumns+songsFrom+" WHERE s.id = ANY($1)", pq.Array(ids)); err != nil {
+		return nil, false, err
+	}
+
+	byId := make(map[int]models.Song, len(songs))
+	for _, song := range songs {
+		byId[song.ID] = song
+	}
+
+	duplicates := make([]models.SongDuplicate, 0, len(found))
+	for _, pair := range found {
+		duplicates = append(duplicates, models.SongDuplicate{
+			Original:  byId[pair.OriginalID],
+			Duplicate: byId[pair.DuplicateID],
+			Score:     pair.Score,
+		})
+	}
+
+	return duplicates, hasMore, nil
+}
+
+// duplicateSongError turns a violation of the song name index into a
+// DuplicateSongError naming the existing song. The violating song is the
+// song id with the given artist and name changes, or a new song for id 0.
+// The existing song is looked up through q, so a write in a transaction sees
+// the songs written before it. Other errors go through dbError.
+func duplicateSongError(q sqlx.Queryer, err error, id int, artistId *int, songName *string) error {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) || pqErr.Constraint != songNameIndex {
+		return dbError(err)
+	}
+
+	query := `SELECT d.id FROM songs d LEFT JOIN songs s ON s.id = $1
+		WHERE d.id <> $1 AND d.duplicate_of IS NULL AND d.deleted_at IS NULL
+			AND d.artist_id = COALESCE($2, s.artist_id)
+			AND song_name_key(d.song_name) = song_name_key(COALESCE($3, s.song_name))`
+
+	var existingId int
+	if lookupErr := sqlx.Get(q, &existingId, query, id, artistId, songName); lookupErr != nil {
+		return dbError(err)
+	}
+
+	return &models.DuplicateSongError{ExistingID: existingId}
+}
+
+// FindSongId returns the id of the live song of that name by the artist known
+// under group, or 0 when there is none. Names match like they do for the
+// uniqueness check, artists also by their aliases.
+func (r *SongPostgres) FindSongId(group, songName string) (int, error) {
+	query := `SELECT s.id FROM songs s JOIN artists a ON a.id = s.artist_id
+		WHERE s.deleted_at IS NULL AND s.duplicate_of IS NULL
+			AND song_name_key(s.song_name) = song_name_key($2)
+			AND (artist_name_key(a.name) = artist_name_key($1)
+				OR artist_alias_keys(a.aliases) @> ARRAY[artist_name_key($1)])
+		ORDER BY artist_name_key(a.name) = artist_name_key($1) DESC, s.id
+		LIMIT 1`
+
+	var id int
+	err := r.db.Get(&id, query, group, songName)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	return id, err
+}
+
+// FindSongIdByLink returns the id of the oldest live song with that link, or 0
+// when there is none.
+func (r *SongPostgres) FindSongIdByLink(link string) (int, error) {
+	var id int
+	err := r.db.Get(&id, "SELECT id FROM songs WHERE link = $1 AND deleted_at IS NULL ORDER BY id LIMIT 1", link)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	return id, err
+}
+
+// MergeSongs folds the source songs into the target in one transaction. merge
+// is called with the locked songs and returns the field values the target
+// keeps. Playlist entries, tags and genres of the sources move to the target
+// before the sources are deleted. The history of the sources, their removal
+// included, moves to the target as the revisions following its current
+// version. With dryRun the merged target is returned and nothing is saved.
+func (r *SongPostgres) MergeSongs(targetId int, sourceIds []int, merge func(target models.Song, sources []models.Song) models.Song, dryRun bool, audit models.Audit) (models.Song, error) {
+	logrus.WithFields(logrus.Fields{
+		"targetId":  targetId,
+		"sourceIds": sourceIds,
+		"dryRun":    dryRun,
+	}).Debug("Merging songs")
+
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return models.Song{}, err
+	}
+	defer tx.Rollback()
+
+	if err := setAudit(tx, audit); err != nil {
+		return models.Song{}, err
+	}
+
+	ids := pq.Array(append([]int{targetId}, sourceIds...))
+	sources := pq.Array(sourceIds)
+
+	var locked []models.Song
+	if err := tx.Select(&locked, "SELECT "+songColumns+songsFrom+" WHERE s.id = ANY($1) ORDER BY s.id FOR UPDATE OF s", ids); err != nil {
+		return models.Song{}, err
+	}
+
+	byId := make(map[int]models.Song, len(locked))
+	for _, song := range locked {
+		byId[song.ID] = song
+	}
+	for _, id := range append([]int{targetId}, sourceIds...) {
+		if _, ok := byId[id]; !ok {
+			return models.Song{}, fmt.Errorf("song with id %d %w", id, models.ErrNotFound)
+		}
+	}
+
+	sourceSongs := make([]models.Song, 0, len(sourceIds))
+	for _, id := range sourceIds {
+		sourceSongs = append(sourceSongs, byId[id])
+	}
+	merged := merge(byId[targetId], sourceSongs)
+
+	statements := []string{
+		"UPDATE playlist_entries SET song_id = $1 WHERE song_id = ANY($2)",
+		"UPDATE songs SET duplicate_of = $1 WHERE duplicate_of = ANY($2)",
+	}
+	for _, t := range []labelTables{tagTables, genreTables} {
+		statements = append(statements, fmt.Sprintf(
+			"INSERT INTO %[1]s (song_id, %[2]s) SELECT $1, %[2]s FROM %[1]s WHERE song_id = ANY($2) ON CONFLICT DO NOTHING",
+			t.joinTable, t.column))
+	}
+	for _, statement := range statements {
+		if _, err := tx.Exec(statement, targetId, sources); err != nil {
+			logrus.WithError(err).Error("Error merging songs")
+			return models.Song{}, dbError(err)
+		}
+	}
+
+	// Sources go before the target takes over their album position.
+	if _, err := tx.Exec("DELETE FROM songs WHERE id = ANY($1)", sources); err != nil {
+		logrus.WithError(err).Error("Error merging songs")
+		return models.Song{}, dbError(err)
+	}
+
+	// Revisions of the sources are renumbered in the order they were made,
+	// after the last version of the target.
+	moveRevisions := `WITH moved AS (
+			SELECT id, row_number() OVER (ORDER BY created_at, id) AS n
+			FROM song_revisions WHERE song_id = ANY($2)
+		)
+		UPDATE song_revisions r SET song_id = $1, revision = $3 + moved.n
+		FROM moved WHERE r.id = moved.id`
+	result, err := tx.Exec(moveRevisions, targetId, sources, byId[targetId].Version)
+	if err != nil {
+		logrus.WithError(err).Error("Error merging songs")
+		return models.Song{}, dbError(err)
+	}
+	moved, err := result.RowsAffected()
+	if err != nil {
+		return models.Song{}, err
+	}
+
+	// The target stops being a duplicate once the song it duplicated is
+	// merged into it. Its version skips the moved revisions, so the merge
+	// itself is recorded after them.
+	update := `UPDATE songs SET release_date = $1, text = $2, link = $3, album_id = $4, disc_number = $5, track_number = $6,
+		duplicate_of = NULLIF(duplicate_of, id), version = version + $7 + 1
+		WHERE id = $8`
+	if _, err := tx.Exec(update, nullIfEmpty(merged.ReleaseDate), nullIfEmpty(merged.Text), nullIfEmpty(merged.Link),
+		merged.AlbumID, merged.DiscNumber, merged.TrackNumber, moved, targetId); err != nil {
+		logrus.WithError(err).Error("Error merging songs")
+		return models.Song{}, dbError(err)
+	}
+
+	var song models.Song
+	if err := tx.Get(&song, "SELECT "+songColumns+songsFrom+" WHERE s.id = $1", targetId); err != nil {
+		return song, err
+	}
+
+	if dryRun {
+		return song, nil
+	}
+
+	if err := tx.Commit(); err != nil {
+		return song, err
+	}
+
+	logrus.WithField("targetId", targetId).Info("Songs successfully merged")
+	return song, nil
+}
@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/AntonZatsepilin/music-library.git/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+// exportFetchSize is how many songs an export reads from its cursor at once.
+const exportFetchSize = 500
+
+// ExportSongs passes every song matching filter, in the order the filter
+// asks for, to each. The songs are read through a cursor in a read-only
+// snapshot, so however long the export takes it neither holds all songs in
+// memory nor sees changes made in the meantime. An error returned by each
+// stops the export and is returned.
+func (r *SongPostgres) ExportSongs(ctx context.Context, filter models.SongFilter, each func(models.Song) error) error {
+	baseQuery, args, fuzzy := songsQuery(filter)
+	orderBy, sortOrder := songsOrder(filter, fuzzy)
+
+	query, queryArgs, err := sqlx.Named(baseQuery+orderClause(orderBy, sortOrder), args)
+	if err != nil {
+		return err
+	}
+	query = r.db.Rebind(query)
+
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if fuzzy && filter.Threshold > 0 {
+		if err := setSimilarityThreshold(tx, filter.Threshold); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, "DECLARE export_songs NO SCROLL CURSOR FOR "+query, queryArgs...); err != nil {
+		return err
+	}
+
+	fetch := fmt.Sprintf("FETCH %d FROM export_songs", exportFetchSize)
+	for {
+		var songs []models.Song
+		if err := tx.SelectContext(ctx, &songs, fetch); err != nil {
+			return err
+		}
+
+		for _, song := range songs {
+			if err := each(song); err != nil {
+				return err
+			}
+		}
+
+		if len(songs) < exportFetchSize {
+			return nil
+		}
+	}
+}
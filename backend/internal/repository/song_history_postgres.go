@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/AntonZatsepilin/music-library.git/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+type songRevisionRow struct {
+	SongID    int       `db:"song_id"`
+	Revision  int       `db:"revision"`
+	Operation string    `db:"operation"`
+	Changes   []byte    `db:"changes"`
+	Actor     *string   `db:"actor"`
+	RequestID *string   `db:"request_id"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// GetSongHistory returns the revisions of a song, newest first. Songs changed
+// only before the history was introduced have none.
+func (r *SongPostgres) GetSongHistory(songId, page, limit int) ([]models.SongRevision, int, error) {
+	var total int
+	if err := r.db.Get(&total, "SELECT COUNT(*) FROM song_revisions WHERE song_id = $1", songId); err != nil {
+		return nil, 0, err
+	}
+
+	var rows []songRevisionRow
+	query := `SELECT song_id, revision, operation, changes, actor, request_id, created_at
+		FROM song_revisions WHERE song_id = $1 ORDER BY revision DESC LIMIT $2 OFFSET $3`
+	if err := r.db.Select(&rows, query, songId, limit, (page-1)*limit); err != nil {
+		return nil, 0, err
+	}
+
+	revisions := make([]models.SongRevision, 0, len(rows))
+	for _, row := range rows {
+		revisions = append(revisions, models.SongRevision{
+			SongID:    row.SongID,
+			Revision:  row.Revision,
+			Operation: row.Operation,
+			Changes:   json.RawMessage(row.Changes),
+			Actor:     row.Actor,
+			RequestID: row.RequestID,
+			CreatedAt: row.CreatedAt,
+		})
+	}
+
+	return revisions, total, nil
+}
+
+// GetSongRevision returns the state of the song at the given revision. Only
+// the stored columns and the current name of the artist are set; tags and
+// genres are not. The group is empty if the artist no longer exists.
+func (r *SongPostgres) GetSongRevision(songId, revision int) (models.Song, error) {
+	query := `SELECT r.song_id AS id,
+			CAST(r.state ->> 'artist_id' AS INT) AS artist_id,
+			COALESCE(a.name, '') AS group_name,
+			r.state ->> 'song_name' AS song_name,
+			COALESCE(r.state ->> 'release_date', '') AS release_date,
+			COALESCE(r.state ->> 'text', '') AS text,
+			COALESCE(r.state ->> 'link', '') AS link
+		FROM song_revisions r LEFT JOIN artists a ON a.id = CAST(r.state ->> 'artist_id' AS INT)
+		WHERE r.song_id = $1 AND r.revision = $2`
+
+	var song models.Song
+	if err := r.db.Get(&song, query, songId, revision); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return song, fmt.Errorf("revision %d of song with id %d %w", revision, songId, models.ErrNotFound)
+		}
+		return song, err
+	}
+
+	return song, nil
+}
+
+// withAudit runs write in a transaction that tells the song history who makes
+// the change.
+func withAudit(db *sqlx.DB, audit models.Audit, write func(tx *sqlx.Tx) error) error {
+	tx, err := db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := setAudit(tx, audit); err != nil {
+		return err
+	}
+
+	if err := write(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func setAudit(tx *sqlx.Tx, audit models.Audit) error {
+	_, err := tx.Exec("SELECT set_config('app.actor', $1, true), set_config('app.request_id', $2, true)",
+		audit.Actor, audit.RequestID)
+	return err
+}
@@ -4,10 +4,12 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/AntonZatsepilin/music-library.git/internal/models"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	"github.com/sirupsen/logrus"
 )
 
@@ -19,135 +21,187 @@ func NewSongPostgres(db *sqlx.DB) *SongPostgres {
 	return &SongPostgres{db: db}
 }
 
-func (r *SongPostgres) CreateSong(song models.Song) error {
+// Songs only store a reference to their artist, so every read joins artists
+// back in to keep exposing the group name. Songs in the trash are left out of
+// the join; trashedSongsFrom reads only them.
+const (
+	songColumns = "s.id, s.artist_id, a.name AS group_name, s.song_name, " +
+		"COALESCE(to_char(s.release_date, 'YYYY-MM-DD'), '') AS release_date, COALESCE(s.text, '') AS text, COALESCE(s.link, '') AS link, s.album_id, s.disc_number, s.track_number, " +
+		"ARRAY(SELECT g.name FROM song_genres sg JOIN genres g ON g.id = sg.genre_id WHERE sg.song_id = s.id ORDER BY g.name) AS genres, " +
+		"ARRAY(SELECT t.name FROM song_tags st JOIN tags t ON t.id = st.tag_id WHERE st.song_id = s.id ORDER BY t.name) AS tags, s.version, s.deleted_at"
+	songsFrom        = " FROM songs s JOIN artists a ON a.id = s.artist_id AND s.deleted_at IS NULL"
+	trashedSongsFrom = " FROM songs s JOIN artists a ON a.id = s.artist_id AND s.deleted_at IS NOT NULL"
+)
+
+// CreateSong inserts the song and returns it as stored, with its id.
+func (r *SongPostgres) CreateSong(song models.Song, audit models.Audit) (models.Song, error) {
 	logrus.WithFields(logrus.Fields{
         "group": song.Group,
         "song":  song.SongName,
     }).Debug("Inserting a song into the database")
-	query := "INSERT INTO songs (group_name, song_name, release_date, text, link) VALUES ($1, $2, $3, $4, $5) RETURNING id"
-	_, err := r.db.Exec(query, song.Group, song.SongName, song.ReleaseDate, song.Text, song.Link)
+
+	var created models.Song
+	err := withAudit(r.db, audit, func(tx *sqlx.Tx) (err error) {
+		created, err = insertSong(tx, song)
+		return err
+	})
 	if err != nil {
 		logrus.WithError(err).Error("Error inserting song")
-		return err
+		return created, duplicateSongError(r.db, err, 0, &song.ArtistID, &song.SongName)
 	}
 
-	logrus.Info("The song has been successfully saved")
-	return nil
+	logrus.WithField("id", created.ID).Info("The song has been successfully saved")
+	return created, nil
+}
+
+func insertSong(q sqlx.Queryer, song models.Song) (models.Song, error) {
+	query := `WITH s AS (
+		INSERT INTO songs (artist_id, song_name, release_date, text, link) VALUES ($1, $2, $3, $4, $5) RETURNING *
+	) SELECT ` + songColumns + " FROM s JOIN artists a ON a.id = s.artist_id"
+
+	var created models.Song
+	err := sqlx.Get(q, &created, query, song.ArtistID, song.SongName, nullIfEmpty(song.ReleaseDate), nullIfEmpty(song.Text), nullIfEmpty(song.Link))
+	return created, err
 }
 
-func (r *SongPostgres) DeleteSongById(id int) error {
+// DeleteSongById moves the song to the trash; with a non-zero version only if
+// the song is still at that version. PurgeDeletedSongs removes it for good.
+func (r *SongPostgres) DeleteSongById(id, version int, audit models.Audit) error {
 
-	_, err := r.GetSongById(id)
+	song, err := r.GetSongById(id)
 	if err != nil {
 		return err
 	}
+	if version != 0 && song.Version != version {
+		return songVersionError(id, song.Version)
+	}
 	
-	logrus.WithField("id", id).Debug("Deleting a song from the database")
-	query := "DELETE FROM songs WHERE id = $1"
-    result, err := r.db.Exec(query, id)
+	logrus.WithField("id", id).Debug("Moving a song to the trash")
+    var affected int64
+    err = withAudit(r.db, audit, func(tx *sqlx.Tx) (err error) {
+        affected, err = trashSong(tx, id, version)
+        return err
+    })
     if err != nil {
         logrus.WithError(err).Error("Database error during deletion")
         return err
     }
     
-    affected, _ := result.RowsAffected()
     if affected == 0 {
-        notFoundErr := fmt.Errorf("song with id %d not found", id)
-        logrus.WithError(notFoundErr).Warn("Attempt to delete non-existing song")
-        return notFoundErr
+        return missingSongError(r.db, id)
     }
     
-    logrus.Info("Song successfully deleted")
+    logrus.Info("Song successfully moved to the trash")
     return nil
 }
 
-func (r *SongPostgres) UpdateSongById(id int, input models.UpdateSongRequest) error {
-
-	_, err := r.GetSongById(id)
+func trashSong(e sqlx.Execer, id, version int) (int64, error) {
+	query := "UPDATE songs SET deleted_at = now() WHERE id = $1 AND deleted_at IS NULL AND ($2 = 0 OR version = $2)"
+	result, err := e.Exec(query, id, version)
 	if err != nil {
-		return err
+		return 0, err
 	}
+	return result.RowsAffected()
+}
 
-
+// UpdateSongById applies changes in a single statement and returns the
+// updated song.
+func (r *SongPostgres) UpdateSongById(id int, changes models.SongChanges, audit models.Audit) (models.Song, error) {
 	logrus.WithField("id", id).Debug("Updating a song in the database")
 
-	queryGroupName := "UPDATE songs SET group_name=$1 WHERE id=$2"
-	querySongName := "UPDATE songs SET song_name=$1 WHERE id=$2"
-	queryReleaseDate := "UPDATE songs SET release_date=$1 WHERE id=$2"
-	queryText := "UPDATE songs SET text=$1 WHERE id=$2"
-	queryLink := "UPDATE songs SET link=$1 WHERE id=$2"
-
-
-	if input.Group != "" {
-			result, err := r.db.Exec(queryGroupName, input.Group, id)
-			if err != nil {
-				logrus.WithError(err).Error("Error updating group name")
-			}
-
-			affected, _ := result.RowsAffected()
-			if affected == 0 {
-				notFoundErr := fmt.Errorf("1: song with id %d not found", id)
-				logrus.WithError(notFoundErr).Warn("1: Attempt to update non-existing song")
-			}
-	}
-
-		if input.Song != "" {
-			result, err := r.db.Exec(querySongName, input.Song, id)
-			if err != nil {
-				logrus.WithError(err).Error("Error updating song name")
-			}
-			affected, _ := result.RowsAffected()
-			if affected == 0 {
-				notFoundErr := fmt.Errorf("2: song with id %d not found", id)
-				logrus.WithError(notFoundErr).Warn("2: Attempt to update non-existing song")
-			}
+	query, args := updateSongQuery(id, changes)
+	if query == "" {
+		song, err := r.GetSongById(id)
+		if err == nil && changes.Version != 0 && song.Version != changes.Version {
+			return song, songVersionError(id, song.Version)
 		}
+		return song, err
+	}
+
+	var song models.Song
+	err := withAudit(r.db, audit, func(tx *sqlx.Tx) error {
+		return tx.Get(&song, query, args...)
+	})
+	if err != nil {
+		return song, updateSongError(r.db, err, id, changes)
+	}
+
+	logrus.Info("Song successfully updated")
+	return song, nil
+}
+
+// updateSongQuery builds the statement applying changes to a live song at the
+// expected version. It returns an empty query when there is nothing to change.
+func updateSongQuery(id int, changes models.SongChanges) (string, []interface{}) {
+	var (
+		sets []string
+		args []interface{}
+	)
+	set := func(column string, value interface{}) {
+		args = append(args, value)
+		sets = append(sets, fmt.Sprintf("%s = $%d", column, len(args)))
+	}
+
+	if changes.ArtistID != nil {
+		set("artist_id", *changes.ArtistID)
+	}
+	if changes.SongName != nil {
+		set("song_name", *changes.SongName)
+	}
+	if changes.ReleaseDate != nil {
+		set("release_date", nullIfEmpty(*changes.ReleaseDate))
+	}
+	if changes.Text != nil {
+		set("text", nullIfEmpty(*changes.Text))
+	}
+	if changes.Link != nil {
+		set("link", nullIfEmpty(*changes.Link))
+	}
+
+	if len(sets) == 0 {
+		return "", nil
+	}
+
+	args = append(args, id, changes.Version)
+	query := fmt.Sprintf(`WITH s AS (
+		UPDATE songs SET %s WHERE id = $%d AND deleted_at IS NULL AND ($%[3]d = 0 OR version = $%[3]d) RETURNING *
+	) SELECT %s FROM s JOIN artists a ON a.id = s.artist_id`, strings.Join(sets, ", "), len(args)-1, len(args), songColumns)
 
-		if input.ReleaseDate != "" {
-			result, err := r.db.Exec(queryReleaseDate, input.ReleaseDate, id)
-			if err != nil {
-				logrus.WithError(err).Error("Error updating release date")
-			}
-			affected, _ := result.RowsAffected()
-			if affected == 0 {
-				notFoundErr := fmt.Errorf("3: song with id %d not found", id)
-				logrus.WithError(notFoundErr).Warn("3: Attempt to update non-existing song")
-			}
-	}
-
-	if input.Text != "" {
-			result, err := r.db.Exec(queryText, input.Text, id)
-			if err != nil {
-				logrus.WithError(err).Error("Error updating text")
-			}
-			affected, _ := result.RowsAffected()
-			if affected == 0 {
-				notFoundErr := fmt.Errorf("4: song with id %d not found", id)
-				logrus.WithError(notFoundErr).Warn("4: Attempt to update non-existing song")				
-			}
-	}
-
-	if input.Link != "" {
-			result, err := r.db.Exec(queryLink, input.Link, id)
-			if err != nil {
-				logrus.WithError(err).Error("Error updating link")
-			}
-			affected, _ := result.RowsAffected()
-			if affected == 0 {
-				notFoundErr := fmt.Errorf("5: song with id %d not found", id)
-				logrus.WithError(notFoundErr).Warn("5: Attempt to update non-existing song")
-			}
-	}
-
-	return nil
+	return query, args
+}
+
+func updateSongError(q sqlx.Queryer, err error, id int, changes models.SongChanges) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return missingSongError(q, id)
+	}
+	logrus.WithError(err).Error("Error updating song")
+	return duplicateSongError(q, err, id, changes.ArtistID, changes.SongName)
+}
+
+// missingSongError explains why a conditional write matched no song: either
+// the song does not exist or it has moved on to another version.
+func missingSongError(q sqlx.Queryer, id int) error {
+	var version int
+	err := sqlx.Get(q, &version, "SELECT version FROM songs WHERE id = $1 AND deleted_at IS NULL", id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("song with id %d %w", id, models.ErrNotFound)
+	}
+	if err != nil {
+		return err
+	}
+	return songVersionError(id, version)
+}
+
+func songVersionError(id, version int) error {
+	return fmt.Errorf("%w: song with id %d is at version %d", models.ErrPreconditionFailed, id, version)
 }
 
 func (r *SongPostgres) GetSongById(id int) (models.Song, error) {
     var song models.Song
-    err := r.db.Get(&song, "SELECT songs.id, songs.group_name, songs.song_name, songs.release_date, songs.text, songs.link FROM songs WHERE id = $1", id)
+    err := r.db.Get(&song, "SELECT "+songColumns+songsFrom+" WHERE s.id = $1", id)
     if err != nil {
         if errors.Is(err, sql.ErrNoRows) {
-            return song, fmt.Errorf("song with id %d not found", id)
+            return song, fmt.Errorf("song with id %d %w", id, models.ErrNotFound)
         }
         return song, err
     }
@@ -155,50 +209,193 @@ func (r *SongPostgres) GetSongById(id int) (models.Song, error) {
 }
 
 func (r *SongPostgres) GetSongs(filter models.SongFilter, page, limit int) ([]models.Song, int, error) {
-    baseQuery := "SELECT * FROM songs WHERE 1=1"
-    args := make(map[string]interface{})
+    songs, total, err := r.GetSongsAfter(filter, nil, (page-1)*limit, limit, true)
+    if err != nil {
+        return nil, 0, err
+    }
+    return songs, *total, nil
+}
+
+// songSortColumns maps the sort_by values to the columns songs are ordered by.
+var songSortColumns = map[string]string{
+    "group":       "a.name",
+    "song":        "s.song_name",
+    "releaseDate": "s.release_date",
+    "text":        "s.text",
+    "link":        "s.link",
+    "score":       "score",
+}
+
+// GetSongsAfter returns the songs matching filter in the requested order, tie
+// broken by id. With after set, the page starts right after that song instead
+// of at offset, which stays stable when songs are added between requests.
+// The total is only counted with withTotal and is nil otherwise.
+func (r *SongPostgres) GetSongsAfter(filter models.SongFilter, after *models.SongCursor, offset, limit int, withTotal bool) ([]models.Song, *int, error) {
+    baseQuery, args, fuzzy := songsQuery(filter)
+
+    // The similarity threshold is a session setting, so fuzzy queries run in
+    // a transaction that sets it locally and leaves the pooled connection as is.
+    var q sqlx.Queryer = r.db
+    if fuzzy && filter.Threshold > 0 {
+        tx, err := r.db.Beginx()
+        if err != nil {
+            return nil, nil, err
+        }
+        defer tx.Rollback()
+
+        if err := setSimilarityThreshold(tx, filter.Threshold); err != nil {
+            return nil, nil, err
+        }
+        q = tx
+    }
+
+    var total *int
+    if withTotal {
+        countQuery, countArgs, err := sqlx.Named(baseQuery, args)
+        if err != nil {
+            return nil, nil, err
+        }
+        countQuery = "SELECT COUNT(*) FROM (" + countQuery + ") AS subquery"
+        countQuery = r.db.Rebind(countQuery)
+
+        total = new(int)
+        if err := sqlx.Get(q, total, countQuery, countArgs...); err != nil {
+            return nil, nil, err
+        }
+    }
+
+    orderBy, sortOrder := songsOrder(filter, fuzzy)
+
+    query := baseQuery
+    if after != nil {
+        query += keysetCondition(orderBy, sortOrder == "DESC", after)
+        args["after_id"] = after.ID
+        if after.Value != nil {
+            args["after_value"] = *after.Value
+        }
+        offset = 0
+    }
+
+    query += orderClause(orderBy, sortOrder) + " LIMIT :limit OFFSET :offset"
+    args["limit"] = limit
+    args["offset"] = offset
+
+    executableQuery, queryArgs, err := sqlx.Named(query, args)
+    if err != nil {
+        return nil, nil, err
+    }
+    executableQuery = r.db.Rebind(executableQuery)
     
+    var songs []models.Song
+    err = sqlx.Select(q, &songs, executableQuery, queryArgs...)
+    if err != nil {
+        return nil, nil, err
+    }
+
+    return songs, total, nil
+}
+
+// songsQuery builds the query selecting the songs matching filter, with named
+// parameters. fuzzy tells whether it selects a similarity score.
+func songsQuery(filter models.SongFilter) (string, map[string]interface{}, bool) {
+    conditions := ""
+    args := make(map[string]interface{})
+    var scores []string
+
     if filter.Group != "" {
-        baseQuery += " AND group_name = :group"
         args["group"] = filter.Group
+        switch filter.Match {
+        case "fuzzy":
+            conditions += " AND a.name % :group"
+            scores = append(scores, "similarity(a.name, :group)")
+        case "prefix":
+            conditions += " AND a.name ILIKE :group_prefix"
+            args["group_prefix"] = escapeLike(strings.TrimSpace(filter.Group)) + "%"
+        default:
+            conditions += " AND artist_name_key(a.name) = artist_name_key(:group)"
+        }
+    }
+    if filter.ArtistID != 0 {
+        conditions += " AND s.artist_id = :artist_id"
+        args["artist_id"] = filter.ArtistID
+    }
+    if filter.AlbumID != 0 {
+        conditions += " AND s.album_id = :album_id"
+        args["album_id"] = filter.AlbumID
     }
     if filter.Song != "" {
-        baseQuery += " AND song_name = :song"
         args["song"] = filter.Song
+        switch filter.Match {
+        case "fuzzy":
+            conditions += " AND s.song_name % :song"
+            scores = append(scores, "similarity(s.song_name, :song)")
+        case "prefix":
+            conditions += " AND s.song_name ILIKE :song_prefix"
+            args["song_prefix"] = escapeLike(strings.TrimSpace(filter.Song)) + "%"
+        default:
+            conditions += " AND s.song_name = :song"
+        }
     }
     if filter.ReleaseDate != "" {
-        baseQuery += " AND release_date = :release_date"
+        conditions += " AND s.release_date = :release_date"
         args["release_date"] = filter.ReleaseDate
     }
+    if filter.ReleasedFrom != "" {
+        conditions += " AND s.release_date >= :released_from"
+        args["released_from"] = filter.ReleasedFrom
+    }
+    if filter.ReleasedTo != "" {
+        conditions += " AND s.release_date <= :released_to"
+        args["released_to"] = filter.ReleasedTo
+    }
+    if filter.Year != 0 {
+        conditions += " AND s.release_date >= :year_start AND s.release_date < :year_end"
+        args["year_start"] = fmt.Sprintf("%04d-01-01", filter.Year)
+        args["year_end"] = fmt.Sprintf("%04d-01-01", filter.Year+1)
+    }
+    if filter.Decade != 0 {
+        conditions += " AND s.release_date >= :decade_start AND s.release_date < :decade_end"
+        args["decade_start"] = fmt.Sprintf("%04d-01-01", filter.Decade)
+        args["decade_end"] = fmt.Sprintf("%04d-01-01", filter.Decade+10)
+    }
     if filter.Text != "" {
-        baseQuery += " AND text ILIKE '%' || :text || '%'"
+        conditions += " AND s.text ILIKE '%' || :text || '%'"
         args["text"] = filter.Text
     }
     if filter.Link != "" {
-        baseQuery += " AND link = :link"
+        conditions += " AND s.link = :link"
         args["link"] = filter.Link
     }
-
-    countQuery, countArgs, err := sqlx.Named(baseQuery, args)
-    if err != nil {
-        return nil, 0, err
+    if len(filter.Genres) > 0 {
+        conditions += labelCondition(genreTables, "genres", filter.GenreMatch == "all")
+        args["genres"] = pq.Array(filter.Genres)
+        args["genres_count"] = len(filter.Genres)
     }
-    countQuery = "SELECT COUNT(*) FROM (" + countQuery + ") AS subquery"
-    countQuery = r.db.Rebind(countQuery)
-    
-    var total int
-    err = r.db.Get(&total, countQuery, countArgs...)
-    if err != nil {
-        return nil, 0, err
+    if len(filter.Tags) > 0 {
+        conditions += labelCondition(tagTables, "tags", filter.TagMatch == "all")
+        args["tags"] = pq.Array(filter.Tags)
+        args["tags_count"] = len(filter.Tags)
+    }
+
+    selectColumns := songColumns
+    if len(scores) > 0 {
+        // Both names are searched when given, so the score is their mean similarity.
+        selectColumns += fmt.Sprintf(", CAST((%s) / %d AS float8) AS score", strings.Join(scores, " + "), len(scores))
     }
+    return "SELECT " + selectColumns + songsFrom + " WHERE 1=1" + conditions, args, len(scores) > 0
+}
+
+func setSimilarityThreshold(tx *sqlx.Tx, threshold float64) error {
+    _, err := tx.Exec("SELECT set_config('pg_trgm.similarity_threshold', $1, true)", strconv.FormatFloat(threshold, 'f', -1, 64))
+    return err
+}
 
-    orderBy := "id"
-    switch filter.SortBy {
-    case "group": orderBy = "group_name"
-    case "song": orderBy = "song_name"
-    case "releaseDate": orderBy = "release_date"
-    case "text": orderBy = "text"
-    case "link": orderBy = "link"
+// songsOrder picks the column and direction songs matching filter are sorted
+// by.
+func songsOrder(filter models.SongFilter, fuzzy bool) (string, string) {
+    orderBy := "s.id"
+    if column, ok := songSortColumns[filter.SortBy]; ok {
+        orderBy = column
     }
 
     sortOrder := "ASC"
@@ -206,21 +403,59 @@ func (r *SongPostgres) GetSongs(filter models.SongFilter, page, limit int) ([]mo
         sortOrder = "DESC"
     }
 
-    query := baseQuery + fmt.Sprintf(" ORDER BY %s %s LIMIT :limit OFFSET :offset", orderBy, sortOrder)
-    args["limit"] = limit
-    args["offset"] = (page - 1) * limit
+    // Fuzzy results are ranked by similarity unless another order is asked for.
+    if fuzzy && (filter.SortBy == "" || filter.SortBy == "score") {
+        orderBy = "score"
+        if filter.SortOrder == "" {
+            sortOrder = "DESC"
+        }
+    }
 
-    executableQuery, queryArgs, err := sqlx.Named(query, args)
-    if err != nil {
-        return nil, 0, err
+    return orderBy, sortOrder
+}
+
+// orderClause orders by column, tie broken by id.
+func orderClause(orderBy, sortOrder string) string {
+    if orderBy == "s.id" {
+        return " ORDER BY s.id " + sortOrder
     }
-    executableQuery = r.db.Rebind(executableQuery)
-    
-    var songs []models.Song
-    err = r.db.Select(&songs, executableQuery, queryArgs...)
-    if err != nil {
-        return nil, 0, err
+    return fmt.Sprintf(" ORDER BY %s %s, s.id %[2]s", orderBy, sortOrder)
+}
+
+// keysetCondition restricts the songs to those ordered after the cursor by
+// "column, s.id". NULLs are handled the way ORDER BY places them: last when
+// ascending and first when descending.
+func keysetCondition(column string, desc bool, after *models.SongCursor) string {
+    op := ">"
+    if desc {
+        op = "<"
     }
 
-    return songs, total, nil
-}
\ No newline at end of file
+    switch {
+    case column == "s.id":
+        return fmt.Sprintf(" AND s.id %s :after_id", op)
+    case after.Value == nil && !desc:
+        return fmt.Sprintf(" AND %s IS NULL AND s.id > :after_id", column)
+    case after.Value == nil:
+        return fmt.Sprintf(" AND (%s IS NOT NULL OR s.id < :after_id)", column)
+    case !desc:
+        return fmt.Sprintf(" AND ((%[1]s, s.id) > (:after_value, :after_id) OR %[1]s IS NULL)", column)
+    default:
+        return fmt.Sprintf(" AND (%s, s.id) < (:after_value, :after_id)", column)
+    }
+}
+
+// escapeLike escapes the LIKE wildcards in value so it is matched literally.
+func escapeLike(value string) string {
+    return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(value)
+}
+
+// nullIfEmpty maps an empty string to NULL, for columns such as release_date
+// where an empty string is not a valid value, and text and link, which are
+// NULL when cleared.
+func nullIfEmpty(value string) interface{} {
+    if value == "" {
+        return nil
+    }
+    return value
+}
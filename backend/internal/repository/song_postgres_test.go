@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/AntonZatsepilin/music-library.git/internal/models"
+)
+
+func TestKeysetCondition(t *testing.T) {
+	value := "2006-07-16"
+
+	tests := []struct {
+		name   string
+		column string
+		desc   bool
+		after  models.SongCursor
+		want   string
+	}{
+		{
+			name:   "id ascending",
+			column: "s.id",
+			after:  models.SongCursor{ID: 5},
+			want:   " AND s.id > :after_id",
+		},
+		{
+			name:   "id descending",
+			column: "s.id",
+			desc:   true,
+			after:  models.SongCursor{ID: 5},
+			want:   " AND s.id < :after_id",
+		},
+		{
+			name:   "null value ascending",
+			column: "s.release_date",
+			after:  models.SongCursor{ID: 5},
+			want:   " AND s.release_date IS NULL AND s.id > :after_id",
+		},
+		{
+			name:   "null value descending",
+			column: "s.release_date",
+			desc:   true,
+			after:  models.SongCursor{ID: 5},
+			want:   " AND (s.release_date IS NOT NULL OR s.id < :after_id)",
+		},
+		{
+			name:   "value ascending",
+			column: "s.release_date",
+			after:  models.SongCursor{Value: &value, ID: 5},
+			want:   " AND ((s.release_date, s.id) > (:after_value, :after_id) OR s.release_date IS NULL)",
+		},
+		{
+			name:   "value descending",
+			column: "s.release_date",
+			desc:   true,
+			after:  models.SongCursor{Value: &value, ID: 5},
+			want:   " AND (s.release_date, s.id) < (:after_value, :after_id)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := keysetCondition(tt.column, tt.desc, &tt.after); got != tt.want {
+				t.Errorf("keysetCondition(%q, %v) = %q, want %q", tt.column, tt.desc, got, tt.want)
+			}
+		})
+	}
+}
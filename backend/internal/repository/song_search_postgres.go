@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"fmt"
+	"html"
+	"strings"
+	"unicode"
+
+	"github.com/AntonZatsepilin/music-library.git/internal/models"
+)
+
+// ts_headline does not escape the text it highlights, so matches are marked
+// with control characters and turned into <b></b> once the text is escaped.
+const (
+	searchConfig     = "english"
+	highlightStart   = "\x02"
+	highlightStop    = "\x03"
+	headlineOptions  = "StartSel=" + highlightStart + ", StopSel=" + highlightStop + ", HighlightAll=true"
+	fragmentOptions  = "StartSel=" + highlightStart + ", StopSel=" + highlightStop + ", MaxFragments=1, MaxWords=30, MinWords=10"
+	maxPrefixQueries = 10
+)
+
+var highlightMarkup = strings.NewReplacer(highlightStart, "<b>", highlightStop, "</b>")
+
+// SearchSongs runs a full-text search over song names and lyrics. The query
+// uses web search syntax ("quoted phrases", OR, -excluded words); words ending
+// with * match as prefixes. Hits are ordered by relevance and carry the first
+// matching verse with the matched words highlighted.
+func (r *SongPostgres) SearchSongs(query string, page, limit int) ([]models.SongSearchHit, int, error) {
+	tsquery, args, err := buildTsQuery(query)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	with := "WITH q AS (SELECT " + tsquery + " AS query) "
+	where := " CROSS JOIN q WHERE s.search_vector @@ q.query AND s.deleted_at IS NULL"
+
+	var total int
+	if err := r.db.Get(&total, with+"SELECT COUNT(*) FROM songs s"+where, args...); err != nil {
+		return nil, 0, err
+	}
+
+	selectQuery := with + fmt.Sprintf(`SELECT %s,
+		ts_rank(s.search_vector, q.query) AS rank,
+		COALESCE(
+			(SELECT ts_headline('%[2]s', v.verse, q.query, '%[3]s')
+				FROM unnest(string_to_array(s.text, E'\n\n')) WITH ORDINALITY AS v(verse, n)
+				WHERE to_tsvector('%[2]s', v.verse) @@ q.query
+				ORDER BY v.n LIMIT 1),
+			ts_headline('%[2]s', s.song_name || E'\n' || COALESCE(s.text, ''), q.query, '%[4]s')
+		) AS headline`, songColumns, searchConfig, headlineOptions, fragmentOptions) +
+		songsFrom + where +
+		fmt.Sprintf(" ORDER BY rank DESC, s.id LIMIT $%d OFFSET $%d", len(args)+1, len(args)+2)
+	args = append(args, limit, (page-1)*limit)
+
+	hits := []models.SongSearchHit{}
+	if err := r.db.Select(&hits, selectQuery, args...); err != nil {
+		return nil, 0, err
+	}
+	for i := range hits {
+		hits[i].Headline = highlightMarkup.Replace(html.EscapeString(hits[i].Headline))
+	}
+
+	return hits, total, nil
+}
+
+// buildTsQuery turns a user query into a tsquery expression with positional
+// parameters. Prefix terms (word*) are split off because websearch_to_tsquery
+// does not support them, and are ANDed with the rest of the query.
+func buildTsQuery(query string) (string, []interface{}, error) {
+	var (
+		rest     []string
+		prefixes []string
+		parts    []string
+		args     []interface{}
+	)
+
+	for _, token := range splitSearchQuery(query) {
+		if strings.HasSuffix(token, "*") && !strings.HasPrefix(token, `"`) && !strings.HasPrefix(token, "-") {
+			if word := strings.TrimFunc(strings.TrimRight(token, "*"), isNotWordRune); word != "" && !strings.ContainsFunc(word, isNotWordRune) {
+				prefixes = append(prefixes, strings.ToLower(word))
+				continue
+			}
+		}
+		rest = append(rest, token)
+	}
+
+	if len(prefixes) > maxPrefixQueries {
+		return "", nil, fmt.Errorf("%w: at most %d prefix terms are allowed", models.ErrValidation, maxPrefixQueries)
+	}
+
+	if remainder := strings.TrimSpace(strings.Join(rest, " ")); remainder != "" {
+		args = append(args, remainder)
+		parts = append(parts, fmt.Sprintf("websearch_to_tsquery('%s', $%d)", searchConfig, len(args)))
+	}
+	for _, prefix := range prefixes {
+		args = append(args, prefix+":*")
+		parts = append(parts, fmt.Sprintf("to_tsquery('%s', $%d)", searchConfig, len(args)))
+	}
+
+	if len(parts) == 0 {
+		return "", nil, fmt.Errorf("%w: search query must not be empty", models.ErrValidation)
+	}
+
+	return strings.Join(parts, " && "), args, nil
+}
+
+// splitSearchQuery splits on whitespace, keeping "quoted phrases" as one token.
+func splitSearchQuery(query string) []string {
+	var (
+		tokens  []string
+		current strings.Builder
+		quoted  bool
+	)
+
+	flush := func() {
+		if current.Len() > 0 {
+			tokens = append(tokens, current.String())
+			current.Reset()
+		}
+	}
+
+	for _, r := range query {
+		switch {
+		case r == '"':
+			current.WriteRune(r)
+			if quoted {
+				flush()
+			}
+			quoted = !quoted
+		case unicode.IsSpace(r) && !quoted:
+			flush()
+		default:
+			current.WriteRune(r)
+		}
+	}
+	flush()
+
+	return tokens
+}
+
+func isNotWordRune(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
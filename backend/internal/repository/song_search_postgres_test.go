@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/AntonZatsepilin/music-library.git/internal/models"
+)
+
+func TestSplitSearchQuery(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{name: "empty", query: "", want: nil},
+		{name: "blank", query: " \t\n", want: nil},
+		{name: "words", query: "love  me\ttender\n", want: []string{"love", "me", "tender"}},
+		{name: "phrase", query: `"love me" tender`, want: []string{`"love me"`, "tender"}},
+		{name: "phrase ends token", query: `say "hello world"again`, want: []string{"say", `"hello world"`, "again"}},
+		{name: "quote inside word", query: `x"y z" w`, want: []string{`x"y z"`, "w"}},
+		{name: "unclosed phrase", query: `tender "love me`, want: []string{"tender", `"love me`}},
+		{name: "operators", query: "love OR -hate", want: []string{"love", "OR", "-hate"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := splitSearchQuery(tt.query); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitSearchQuery(%q) = %q, want %q", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildTsQuery(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		wantExpr string
+		wantArgs []interface{}
+		wantErr  bool
+	}{
+		{
+			name:     "words",
+			query:    "love me",
+			wantExpr: "websearch_to_tsquery('english', $1)",
+			wantArgs: []interface{}{"love me"},
+		},
+		{
+			name:     "prefix",
+			query:    "Lov*",
+			wantExpr: "to_tsquery('english', $1)",
+			wantArgs: []interface{}{"lov:*"},
+		},
+		{
+			name:     "prefix and words",
+			query:    "tend* love me",
+			wantExpr: "websearch_to_tsquery('english', $1) && to_tsquery('english', $2)",
+			wantArgs: []interface{}{"love me", "tend:*"},
+		},
+		{
+			name:     "prefix with punctuation around",
+			query:    "'lov**",
+			wantExpr: "to_tsquery('english', $1)",
+			wantArgs: []interface{}{"lov:*"},
+		},
+		{
+			name:     "star in phrase",
+			query:    `"love me*"`,
+			wantExpr: "websearch_to_tsquery('english', $1)",
+			wantArgs: []interface{}{`"love me*"`},
+		},
+		{
+			name:     "excluded prefix",
+			query:    "love -hat*",
+			wantExpr: "websearch_to_tsquery('english', $1)",
+			wantArgs: []interface{}{"love -hat*"},
+		},
+		{
+			name:     "prefix of several words",
+			query:    "rock'n*",
+			wantExpr: "websearch_to_tsquery('english', $1)",
+			wantArgs: []interface{}{"rock'n*"},
+		},
+		{
+			name:     "bare star",
+			query:    "*",
+			wantExpr: "websearch_to_tsquery('english', $1)",
+			wantArgs: []interface{}{"*"},
+		},
+		{name: "empty", query: "", wantErr: true},
+		{name: "blank", query: "  ", wantErr: true},
+		{name: "too many prefixes", query: strings.Repeat("a* ", maxPrefixQueries+1), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, args, err := buildTsQuery(tt.query)
+			if tt.wantErr {
+				if !errors.Is(err, models.ErrValidation) {
+					t.Fatalf("buildTsQuery(%q) error = %v, want ErrValidation", tt.query, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("buildTsQuery(%q) unexpected error: %v", tt.query, err)
+			}
+			if expr != tt.wantExpr {
+				t.Errorf("buildTsQuery(%q) expression = %q, want %q", tt.query, expr, tt.wantExpr)
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("buildTsQuery(%q) args = %q, want %q", tt.query, args, tt.wantArgs)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/AntonZatsepilin/music-library.git/internal/models"
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+)
+
+// GetTrashedSongs returns the songs in the trash, most recently deleted first.
+func (r *SongPostgres) GetTrashedSongs(page, limit int) ([]models.Song, int, error) {
+	var total int
+	if err := r.db.Get(&total, "SELECT COUNT(*) FROM songs WHERE deleted_at IS NOT NULL"); err != nil {
+		return nil, 0, err
+	}
+
+	songs := []models.Song{}
+	query := "SELECT " + songColumns + trashedSongsFrom + " ORDER BY s.deleted_at DESC, s.id DESC LIMIT $1 OFFSET $2"
+	if err := r.db.Select(&songs, query, limit, (page-1)*limit); err != nil {
+		return nil, 0, err
+	}
+
+	return songs, total, nil
+}
+
+// RestoreSong takes the song out of the trash and returns it.
+func (r *SongPostgres) RestoreSong(id int, audit models.Audit) (models.Song, error) {
+	query := `WITH s AS (
+		UPDATE songs SET deleted_at = NULL WHERE id = $1 AND deleted_at IS NOT NULL RETURNING *
+	) SELECT ` + songColumns + " FROM s JOIN artists a ON a.id = s.artist_id"
+
+	var song models.Song
+	err := withAudit(r.db, audit, func(tx *sqlx.Tx) error {
+		return tx.Get(&song, query, id)
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return song, fmt.Errorf("song with id %d %w in the trash", id, models.ErrNotFound)
+		}
+		logrus.WithError(err).Error("Error restoring song")
+		return song, duplicateSongError(r.db, err, id, nil, nil)
+	}
+
+	logrus.WithField("id", id).Info("The song has been restored from the trash")
+	return song, nil
+}
+
+// PurgeDeletedSongs permanently removes the songs moved to the trash before
+// the given time and returns how many were removed. Songs marked as
+// duplicates of a purged song are first pointed at the live song they
+// duplicate, or else at the first of them, which stops being a duplicate.
+// Letting the foreign key clear them all could break the uniqueness of names.
+func (r *SongPostgres) PurgeDeletedSongs(before time.Time) (int64, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	repoint := `UPDATE songs s SET duplicate_of = d.original_id
+		FROM (
+			SELECT o.id, COALESCE(
+				(SELECT l.id FROM songs l
+					WHERE l.artist_id = o.artist_id AND song_name_key(l.song_name) = song_name_key(o.song_name)
+						AND l.duplicate_of IS NULL AND l.deleted_at IS NULL
+					ORDER BY l.id LIMIT 1),
+				NULLIF(min(o.id) OVER (PARTITION BY o.artist_id, song_name_key(o.song_name)), o.id)
+			) AS original_id
+			FROM songs o
+			WHERE o.duplicate_of IN (SELECT id FROM songs WHERE deleted_at < $1)
+				AND (o.deleted_at IS NULL OR o.deleted_at >= $1)
+		) d
+		WHERE d.id = s.id`
+	if _, err := tx.Exec(repoint, before); err != nil {
+		logrus.WithError(err).Error("Error purging the trash")
+		return 0, err
+	}
+
+	result, err := tx.Exec("DELETE FROM songs WHERE deleted_at < $1", before)
+	if err != nil {
+		logrus.WithError(err).Error("Error purging the trash")
+		return 0, err
+	}
+
+	purged, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return purged, tx.Commit()
+}
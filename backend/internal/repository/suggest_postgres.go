@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/AntonZatsepilin/music-library.git/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+// minInfixLength is the shortest query matched inside names. Shorter queries
+// have no trigrams to look up, so they only match name prefixes.
+const minInfixLength = 3
+
+type SuggestPostgres struct {
+	db *sqlx.DB
+}
+
+func NewSuggestPostgres(db *sqlx.DB) *SuggestPostgres {
+	return &SuggestPostgres{db: db}
+}
+
+// suggestSource is a table of distinct names with their song counts.
+type suggestSource struct {
+	table  string
+	column string
+}
+
+var suggestSources = map[string]suggestSource{
+	"group": {table: "artists", column: "name"},
+	"song":  {table: "song_titles", column: "title"},
+}
+
+// Suggest returns names of the given kind containing query, most used first.
+// Names starting with query are listed before names containing it elsewhere.
+// Each lookup sorts the names it matches by song count, so its cost grows
+// with the number of matching names; for short, common prefixes the planner
+// can walk the song count index instead and stop at limit.
+func (r *SuggestPostgres) Suggest(kind, query string, limit int) ([]models.Suggestion, error) {
+	source, ok := suggestSources[kind]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown suggestion type %q", models.ErrValidation, kind)
+	}
+
+	pattern := escapeLike(strings.ToLower(query))
+	prefixQuery := fmt.Sprintf(`SELECT %[1]s AS name, song_count AS count, 0 AS rank FROM %[2]s
+		WHERE lower(%[1]s) LIKE $1 AND song_count > 0
+		ORDER BY song_count DESC, %[1]s LIMIT $2`, source.column, source.table)
+	args := []interface{}{pattern + "%", limit}
+
+	sqlQuery := "(" + prefixQuery + ")"
+	if len([]rune(query)) >= minInfixLength {
+		sqlQuery += fmt.Sprintf(` UNION ALL (SELECT %[1]s, song_count, 1 FROM %[2]s
+			WHERE %[1]s ILIKE $3 AND lower(%[1]s) NOT LIKE $1 AND song_count > 0
+			ORDER BY song_count DESC, %[1]s LIMIT $2)`, source.column, source.table)
+		args = append(args, "%"+pattern+"%")
+	}
+	sqlQuery = "SELECT name, count FROM (" + sqlQuery + ") AS matches ORDER BY rank, count DESC, name LIMIT $2"
+
+	suggestions := []models.Suggestion{}
+	if err := r.db.Select(&suggestions, sqlQuery, args...); err != nil {
+		return nil, err
+	}
+
+	return suggestions, nil
+}
+
+// RefreshSuggestions recomputes the distinct song titles and the song counts
+// of artists from the songs that are not in the trash.
+func (r *SuggestPostgres) RefreshSuggestions() error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	titles := `WITH counts AS (
+			SELECT song_name AS title, COUNT(*) AS song_count FROM songs WHERE deleted_at IS NULL GROUP BY song_name
+		), removed AS (
+			DELETE FROM song_titles t WHERE NOT EXISTS (SELECT 1 FROM counts c WHERE c.title = t.title)
+		)
+		INSERT INTO song_titles (title, song_count) SELECT title, song_count FROM counts
+		ON CONFLICT (title) DO UPDATE SET song_count = EXCLUDED.song_count
+		WHERE song_titles.song_count <> EXCLUDED.song_count`
+	if _, err := tx.Exec(titles); err != nil {
+		return err
+	}
+
+	artists := `UPDATE artists a SET song_count = c.song_count
+		FROM (
+			SELECT artists.id, COUNT(s.id) AS song_count
+			FROM artists LEFT JOIN songs s ON s.artist_id = artists.id AND s.deleted_at IS NULL
+			GROUP BY artists.id
+		) c
+		WHERE c.id = a.id AND a.song_count <> c.song_count`
+	if _, err := tx.Exec(artists); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,149 @@
+package repository
+
+import (
+	"fmt"
+
+	"github.com/AntonZatsepilin/music-library.git/internal/models"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+)
+
+type TagPostgres struct {
+	db *sqlx.DB
+}
+
+func NewTagPostgres(db *sqlx.DB) *TagPostgres {
+	return &TagPostgres{db: db}
+}
+
+// labelTables describes a classification attached to songs through a join table.
+// Tags and genres share the same shape and only differ in table names.
+type labelTables struct {
+	kind      string
+	table     string
+	joinTable string
+	column    string
+}
+
+var (
+	tagTables   = labelTables{kind: "tag", table: "tags", joinTable: "song_tags", column: "tag_id"}
+	genreTables = labelTables{kind: "genre", table: "genres", joinTable: "song_genres", column: "genre_id"}
+)
+
+func (r *TagPostgres) AddSongTags(songId int, tags []string) error {
+	return r.attach(tagTables, songId, tags)
+}
+
+func (r *TagPostgres) RemoveSongTag(songId int, tag string) error {
+	return r.detach(tagTables, songId, tag)
+}
+
+func (r *TagPostgres) GetTagCounts() ([]models.TagCount, error) {
+	return r.counts(tagTables)
+}
+
+func (r *TagPostgres) AddSongGenres(songId int, genres []string) error {
+	return r.attach(genreTables, songId, genres)
+}
+
+func (r *TagPostgres) RemoveSongGenre(songId int, genre string) error {
+	return r.detach(genreTables, songId, genre)
+}
+
+func (r *TagPostgres) GetGenreCounts() ([]models.TagCount, error) {
+	return r.counts(genreTables)
+}
+
+func (r *TagPostgres) attach(t labelTables, songId int, names []string) error {
+	logrus.WithFields(logrus.Fields{
+		"songId": songId,
+		t.kind:   names,
+	}).Debugf("Attaching %ss to a song", t.kind)
+
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	if err := tx.Get(&exists, "SELECT EXISTS (SELECT 1 FROM songs WHERE id = $1 AND deleted_at IS NULL)", songId); err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("song with id %d %w", songId, models.ErrNotFound)
+	}
+
+	insertLabels := fmt.Sprintf("INSERT INTO %s (name) SELECT unnest($1::text[]) ON CONFLICT (name) DO NOTHING", t.table)
+	if _, err := tx.Exec(insertLabels, pq.Array(names)); err != nil {
+		logrus.WithError(err).Errorf("Error inserting %ss", t.kind)
+		return err
+	}
+
+	insertLinks := fmt.Sprintf("INSERT INTO %s (song_id, %s) SELECT $1, id FROM %s WHERE name = ANY($2) ON CONFLICT DO NOTHING",
+		t.joinTable, t.column, t.table)
+	if _, err := tx.Exec(insertLinks, songId, pq.Array(names)); err != nil {
+		logrus.WithError(err).Errorf("Error attaching %ss", t.kind)
+		return err
+	}
+
+	if _, err := tx.Exec("UPDATE songs SET version = version + 1 WHERE id = $1", songId); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *TagPostgres) detach(t labelTables, songId int, name string) error {
+	logrus.WithFields(logrus.Fields{
+		"songId": songId,
+		t.kind:   name,
+	}).Debugf("Detaching a %s from a song", t.kind)
+
+	query := fmt.Sprintf(`WITH deleted AS (
+			DELETE FROM %s WHERE song_id = $1 AND %s = (SELECT id FROM %s WHERE name = $2) RETURNING song_id
+		)
+		UPDATE songs SET version = version + 1 WHERE id IN (SELECT song_id FROM deleted)`,
+		t.joinTable, t.column, t.table)
+	result, err := r.db.Exec(query, songId, name)
+	if err != nil {
+		logrus.WithError(err).Errorf("Error detaching %s", t.kind)
+		return err
+	}
+
+	affected, _ := result.RowsAffected()
+	if affected == 0 {
+		return fmt.Errorf("%s %q of song with id %d %w", t.kind, name, songId, models.ErrNotFound)
+	}
+
+	return nil
+}
+
+// counts returns how many songs have each label. Songs in the trash are not
+// counted.
+func (r *TagPostgres) counts(t labelTables) ([]models.TagCount, error) {
+	query := fmt.Sprintf(`SELECT l.name, COUNT(s.id) AS count
+		FROM %s l LEFT JOIN %s j ON j.%s = l.id
+		LEFT JOIN songs s ON s.id = j.song_id AND s.deleted_at IS NULL
+		GROUP BY l.id
+		ORDER BY count DESC, l.name`, t.table, t.joinTable, t.column)
+
+	counts := []models.TagCount{}
+	if err := r.db.Select(&counts, query); err != nil {
+		return nil, err
+	}
+
+	return counts, nil
+}
+
+// labelCondition builds the GetSongs condition restricting songs to those having
+// any (or, with matchAll, every one) of the given names.
+func labelCondition(t labelTables, param string, matchAll bool) string {
+	condition := fmt.Sprintf(" AND s.id IN (SELECT j.song_id FROM %s j JOIN %s l ON l.id = j.%s WHERE l.name = ANY(:%s)",
+		t.joinTable, t.table, t.column, param)
+	if matchAll {
+		condition += fmt.Sprintf(" GROUP BY j.song_id HAVING COUNT(*) = :%s_count", param)
+	}
+	return condition + ")"
+}
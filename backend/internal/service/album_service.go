@@ -0,0 +1,99 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/AntonZatsepilin/music-library.git/internal/models"
+	"github.com/AntonZatsepilin/music-library.git/internal/repository"
+	"github.com/sirupsen/logrus"
+)
+
+type AlbumServiceImpl struct {
+	repo       repository.AlbumRepository
+	artistRepo repository.ArtistRepository
+}
+
+func NewAlbumService(repo repository.AlbumRepository, artistRepo repository.ArtistRepository) *AlbumServiceImpl {
+	return &AlbumServiceImpl{
+		repo:       repo,
+		artistRepo: artistRepo,
+	}
+}
+
+func (s *AlbumServiceImpl) CreateAlbum(input models.CreateAlbumRequest) (models.Album, error) {
+	group := normalizeName(input.Group)
+	title := normalizeName(input.Title)
+	if group == "" || title == "" {
+		return models.Album{}, fmt.Errorf("%w: group and title must not be empty", models.ErrValidation)
+	}
+
+	artistId, err := s.artistRepo.ResolveArtist(group)
+	if err != nil {
+		return models.Album{}, err
+	}
+
+	logrus.WithField("title", title).Debug("Saving an album to the database")
+	id, err := s.repo.CreateAlbum(models.Album{
+		ArtistID:    artistId,
+		Title:       title,
+		ReleaseYear: input.ReleaseYear,
+	})
+	if err != nil {
+		return models.Album{}, err
+	}
+
+	return s.repo.GetAlbumById(id)
+}
+
+func (s *AlbumServiceImpl) GetAlbums(filter models.AlbumFilter, page, limit int) ([]models.Album, int, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 10
+	} else if limit > 100 {
+		limit = 100
+	}
+
+	return s.repo.GetAlbums(filter, page, limit)
+}
+
+func (s *AlbumServiceImpl) GetAlbumById(id int) (models.Album, error) {
+	return s.repo.GetAlbumById(id)
+}
+
+func (s *AlbumServiceImpl) UpdateAlbumById(id int, input models.UpdateAlbumRequest) error {
+	if group := normalizeName(input.Group); group != "" {
+		artistId, err := s.artistRepo.ResolveArtist(group)
+		if err != nil {
+			return err
+		}
+		input.ArtistID = artistId
+	}
+	input.Title = normalizeName(input.Title)
+
+	return s.repo.UpdateAlbumById(id, input)
+}
+
+func (s *AlbumServiceImpl) DeleteAlbumById(id int, audit models.Audit) error {
+	return s.repo.DeleteAlbumById(id, audit)
+}
+
+func (s *AlbumServiceImpl) GetAlbumTracks(id int) ([]models.Song, error) {
+	return s.repo.GetAlbumTracks(id)
+}
+
+func (s *AlbumServiceImpl) SetAlbumTrack(albumId, songId int, input models.AlbumTrackRequest, audit models.Audit) error {
+	if input.Disc == 0 {
+		input.Disc = 1
+	}
+	if input.Disc < 1 || input.Track < 1 {
+		return fmt.Errorf("%w: disc and track numbers must be positive", models.ErrValidation)
+	}
+
+	return s.repo.SetAlbumTrack(albumId, songId, input.Disc, input.Track, audit)
+}
+
+func (s *AlbumServiceImpl) RemoveAlbumTrack(albumId, songId int, audit models.Audit) error {
+	return s.repo.RemoveAlbumTrack(albumId, songId, audit)
+}
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/AntonZatsepilin/music-library.git/internal/models"
+	"github.com/AntonZatsepilin/music-library.git/internal/repository"
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+)
+
+type ArtistServiceImpl struct {
+	repo     repository.ArtistRepository
+	songRepo repository.SongRepository
+}
+
+func NewArtistService(repo repository.ArtistRepository, songRepo repository.SongRepository) *ArtistServiceImpl {
+	return &ArtistServiceImpl{
+		repo:     repo,
+		songRepo: songRepo,
+	}
+}
+
+// normalizeName trims a name and collapses runs of whitespace, so that
+// "Muse" and " Muse " end up referring to the same artist.
+func normalizeName(name string) string {
+	return strings.Join(strings.Fields(name), " ")
+}
+
+func normalizeNames(names []string) []string {
+	if names == nil {
+		return nil
+	}
+
+	result := make([]string, 0, len(names))
+	for _, name := range names {
+		if name = normalizeName(name); name != "" {
+			result = append(result, name)
+		}
+	}
+	return result
+}
+
+func (s *ArtistServiceImpl) CreateArtist(input models.CreateArtistRequest) (models.Artist, error) {
+	artist := models.Artist{
+		Name:       normalizeName(input.Name),
+		SortName:   normalizeName(input.SortName),
+		Country:    strings.TrimSpace(input.Country),
+		FormedYear: input.FormedYear,
+		Aliases:    pq.StringArray(normalizeNames(input.Aliases)),
+	}
+	if artist.Name == "" {
+		return models.Artist{}, fmt.Errorf("%w: artist name must not be empty", models.ErrValidation)
+	}
+
+	logrus.WithField("name", artist.Name).Debug("Saving an artist to the database")
+	id, err := s.repo.CreateArtist(artist)
+	if err != nil {
+		return models.Artist{}, err
+	}
+
+	return s.repo.GetArtistById(id)
+}
+
+func (s *ArtistServiceImpl) GetArtists(name string, page, limit int) ([]models.Artist, int, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 10
+	} else if limit > 100 {
+		limit = 100
+	}
+
+	return s.repo.GetArtists(strings.TrimSpace(name), page, limit)
+}
+
+func (s *ArtistServiceImpl) GetArtistById(id int) (models.Artist, error) {
+	return s.repo.GetArtistById(id)
+}
+
+func (s *ArtistServiceImpl) UpdateArtistById(id int, input models.UpdateArtistRequest) error {
+	input.Name = normalizeName(input.Name)
+	input.SortName = normalizeName(input.SortName)
+	input.Country = strings.TrimSpace(input.Country)
+	input.Aliases = normalizeNames(input.Aliases)
+
+	return s.repo.UpdateArtistById(id, input)
+}
+
+func (s *ArtistServiceImpl) DeleteArtistById(id int) error {
+	return s.repo.DeleteArtistById(id)
+}
+
+func (s *ArtistServiceImpl) GetArtistSongs(id int, page, limit int) ([]models.Song, int, error) {
+	if _, err := s.repo.GetArtistById(id); err != nil {
+		return nil, 0, err
+	}
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 10
+	} else if limit > 100 {
+		limit = 100
+	}
+
+	return s.songRepo.GetSongs(models.SongFilter{ArtistID: id}, page, limit)
+}
@@ -0,0 +1,131 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/AntonZatsepilin/music-library.git/internal/models"
+	"github.com/AntonZatsepilin/music-library.git/internal/repository"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	defaultIdempotencyTTL           = 24 * time.Hour
+	defaultIdempotencyLease         = time.Minute
+	defaultIdempotencyPurgeInterval = time.Hour
+)
+
+type IdempotencyServiceImpl struct {
+	repo  repository.IdempotencyRepository
+	ttl   time.Duration
+	lease time.Duration
+}
+
+func NewIdempotencyService(repo repository.IdempotencyRepository, ttl, lease time.Duration) *IdempotencyServiceImpl {
+	if ttl <= 0 {
+		ttl = defaultIdempotencyTTL
+	}
+	if lease <= 0 {
+		lease = defaultIdempotencyLease
+	}
+	return &IdempotencyServiceImpl{repo: repo, ttl: ttl, lease: lease}
+}
+
+// BeginIdempotentRequest claims the key for a request. It returns the saved
+// response when the request is a retry, or the token the request holds the
+// key with when it has to be processed. Reusing a key for a different request
+// is a validation error; a retry while the first request holds the key is a
+// conflict until its lease runs out.
+func (s *IdempotencyServiceImpl) BeginIdempotentRequest(scope, key, requestHash string) (*models.IdempotentResponse, string, error) {
+	token, err := newIdempotencyToken()
+	if err != nil {
+		return nil, "", err
+	}
+
+	record, err := s.repo.ReserveIdempotencyKey(scope, key, requestHash, token, s.ttl, s.lease)
+	if err != nil {
+		return nil, "", err
+	}
+	if record == nil {
+		return nil, token, nil
+	}
+
+	if record.RequestHash != requestHash {
+		return nil, "", fmt.Errorf("%w: idempotency key %q was already used with a different request", models.ErrValidation, key)
+	}
+
+	if record.Response == nil {
+		return nil, "", fmt.Errorf("%w: a request with idempotency key %q is still in progress", models.ErrConflict, key)
+	}
+
+	return record.Response, "", nil
+}
+
+// HoldIdempotentRequest renews the lease on the key until ctx is done, so a
+// request that runs longer than the lease keeps its key.
+func (s *IdempotencyServiceImpl) HoldIdempotentRequest(ctx context.Context, scope, key, token string) {
+	ticker := time.NewTicker(s.lease / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := s.repo.RenewIdempotencyKey(scope, key, token); err != nil {
+			logrus.WithError(err).Error("Error renewing idempotency key")
+		}
+	}
+}
+
+// CompleteIdempotentRequest saves the response for replay to retries. It is a
+// conflict if another request has taken the key over.
+func (s *IdempotencyServiceImpl) CompleteIdempotentRequest(scope, key, token string, response models.IdempotentResponse) error {
+	return s.repo.SaveIdempotentResponse(scope, key, token, response)
+}
+
+// AbandonIdempotentRequest frees the key of a request that failed, so the
+// client can retry it. A key taken over by another request is left alone.
+func (s *IdempotencyServiceImpl) AbandonIdempotentRequest(scope, key, token string) error {
+	return s.repo.ReleaseIdempotencyKey(scope, key, token)
+}
+
+// newIdempotencyToken returns a random token identifying a request that holds
+// an idempotency key.
+func newIdempotencyToken() (string, error) {
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(token), nil
+}
+
+// RunIdempotencyKeyPurge deletes expired idempotency keys every interval
+// until ctx is done.
+func (s *IdempotencyServiceImpl) RunIdempotencyKeyPurge(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = defaultIdempotencyPurgeInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		purged, err := s.repo.PurgeIdempotencyKeys(s.ttl)
+		if err != nil {
+			logrus.WithError(err).Error("Idempotency key purge failed")
+		} else if purged > 0 {
+			logrus.WithField("count", purged).Info("Purged expired idempotency keys")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
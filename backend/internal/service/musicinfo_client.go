@@ -2,8 +2,10 @@ package service
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"time"
 
@@ -34,6 +36,15 @@ func (e *APIError) Error() string {
     return fmt.Sprintf("API request failed: status %d, body %s", e.StatusCode, e.Body)
 }
 
+// Unwrap reports a 404 as an unknown song and any other status as the
+// service being unavailable.
+func (e *APIError) Unwrap() error {
+    if e.StatusCode == http.StatusNotFound {
+        return models.ErrNotFound
+    }
+    return models.ErrUpstreamUnavailable
+}
+
 func (c *MusicInfoClient) GetSongDetail(group, song string) (*models.SongDetail, error) {
 
     logrus.WithFields(logrus.Fields{
@@ -52,7 +63,11 @@ func (c *MusicInfoClient) GetSongDetail(group, song string) (*models.SongDetail,
 
     resp, err := c.client.Do(req)
     if err != nil {
-        return nil, err
+        var netErr net.Error
+        if errors.As(err, &netErr) && netErr.Timeout() {
+            return nil, fmt.Errorf("%w: %w", models.ErrUpstreamTimeout, err)
+        }
+        return nil, fmt.Errorf("%w: %w", models.ErrUpstreamUnavailable, err)
     }
     defer resp.Body.Close()
 
@@ -68,7 +83,7 @@ func (c *MusicInfoClient) GetSongDetail(group, song string) (*models.SongDetail,
 
     var detail models.SongDetail
     if err := json.NewDecoder(resp.Body).Decode(&detail); err != nil {
-        return nil, err
+        return nil, fmt.Errorf("%w: invalid response: %w", models.ErrUpstreamUnavailable, err)
     }
 
     return &detail, nil
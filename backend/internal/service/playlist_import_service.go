@@ -0,0 +1,197 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/AntonZatsepilin/music-library.git/internal/models"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	maxPlaylistImportEntries    = 1000
+	defaultImportedPlaylistName = "Imported playlist"
+)
+
+// ImportPlaylist creates a playlist of the owner from a playlist file. Each
+// entry is matched to a song by its link, then by its creator and title,
+// exactly and then fuzzily. With options.Create the entries that match
+// nothing become new songs enriched by the music info API, several at a time;
+// an entry repeating an earlier one matches the song created for it. The
+// playlist holds the matched and created songs in file order. When no entry
+// matches a song no playlist is created and only the report is returned.
+func (s *PlaylistServiceImpl) ImportPlaylist(owner string, file models.PlaylistFile, options models.PlaylistImportOptions, audit models.Audit) (models.PlaylistImportResponse, error) {
+	if len(file.Entries) == 0 {
+		return models.PlaylistImportResponse{}, fmt.Errorf("%w: the playlist file has no entries", models.ErrValidation)
+	}
+	if len(file.Entries) > maxPlaylistImportEntries {
+		return models.PlaylistImportResponse{}, fmt.Errorf("%w: a playlist file holds at most %d entries", models.ErrValidation, maxPlaylistImportEntries)
+	}
+	if options.Threshold < 0 || options.Threshold > 1 {
+		return models.PlaylistImportResponse{}, fmt.Errorf("%w: threshold must be between 0 and 1", models.ErrValidation)
+	}
+
+	name := normalizeName(options.Name)
+	if name == "" {
+		name = normalizeName(file.Title)
+	}
+	if name == "" {
+		name = defaultImportedPlaylistName
+	}
+
+	entries := make([]models.PlaylistImportEntry, len(file.Entries))
+	var creates []int
+	firstOf := make(map[string]int)
+	repeats := make(map[int]int)
+	for i, fileEntry := range file.Entries {
+		var create bool
+		entries[i], create = s.matchPlaylistEntry(fileEntry, options)
+		entries[i].Position = i + 1
+		if !create {
+			continue
+		}
+
+		key := strings.ToLower(entries[i].Creator) + "\x00" + strings.ToLower(entries[i].Title)
+		if first, ok := firstOf[key]; ok {
+			repeats[i] = first
+			continue
+		}
+		firstOf[key] = i
+		creates = append(creates, i)
+	}
+
+	forEachConcurrently(len(creates), s.batchWorkers, func(j int) {
+		i := creates[j]
+		entries[i] = s.createPlaylistEntry(entries[i], audit)
+	})
+	for i, first := range repeats {
+		entries[i].Status, entries[i].SongID, entries[i].Err = entries[first].Status, entries[first].SongID, entries[first].Err
+		if entries[i].Status != models.PlaylistEntryFailed {
+			entries[i].Status, entries[i].Match = models.PlaylistEntryMatched, "exact"
+		}
+	}
+
+	response := models.PlaylistImportResponse{Entries: entries}
+	songIds := make([]int, 0, len(entries))
+	for _, entry := range entries {
+		switch entry.Status {
+		case models.PlaylistEntryMatched:
+			response.Matched++
+		case models.PlaylistEntryCreated:
+			response.Created++
+		case models.PlaylistEntryUnmatched:
+			response.Unmatched++
+		default:
+			response.Failed++
+		}
+		if entry.SongID != 0 {
+			songIds = append(songIds, entry.SongID)
+		}
+	}
+
+	if len(songIds) == 0 {
+		logrus.WithField("unmatched", response.Unmatched).Info("No entry of the playlist file matches a song")
+		return response, nil
+	}
+
+	id, err := s.repo.CreatePlaylistWithSongs(owner, name, songIds)
+	if err != nil {
+		return models.PlaylistImportResponse{}, err
+	}
+
+	playlist, err := s.repo.GetPlaylistById(id)
+	if err != nil {
+		return models.PlaylistImportResponse{}, err
+	}
+	response.Playlist = &playlist
+
+	logrus.WithFields(logrus.Fields{
+		"id":        id,
+		"matched":   response.Matched,
+		"created":   response.Created,
+		"unmatched": response.Unmatched,
+		"failed":    response.Failed,
+	}).Info("Playlist imported")
+	return response, nil
+}
+
+// matchPlaylistEntry matches a playlist file entry to a song and tells whether
+// an unmatched entry is to become a new song.
+func (s *PlaylistServiceImpl) matchPlaylistEntry(fileEntry models.PlaylistFileEntry, options models.PlaylistImportOptions) (models.PlaylistImportEntry, bool) {
+	entry := models.PlaylistImportEntry{
+		Location: strings.TrimSpace(fileEntry.Location),
+		Creator:  normalizeName(fileEntry.Creator),
+		Title:    normalizeName(fileEntry.Title),
+	}
+
+	matched := func(match string, songId int) (models.PlaylistImportEntry, bool) {
+		entry.Status, entry.Match, entry.SongID = models.PlaylistEntryMatched, match, songId
+		return entry, false
+	}
+	failed := func(err error) (models.PlaylistImportEntry, bool) {
+		entry.Status, entry.Err = models.PlaylistEntryFailed, err
+		return entry, false
+	}
+
+	if entry.Location != "" {
+		songId, err := s.songRepo.FindSongIdByLink(entry.Location)
+		if err != nil {
+			return failed(err)
+		}
+		if songId != 0 {
+			return matched("link", songId)
+		}
+	}
+
+	if entry.Title == "" {
+		entry.Status = models.PlaylistEntryUnmatched
+		return entry, false
+	}
+
+	if entry.Creator != "" {
+		songId, err := s.songRepo.FindSongId(entry.Creator, entry.Title)
+		if err != nil {
+			return failed(err)
+		}
+		if songId != 0 {
+			return matched("exact", songId)
+		}
+	}
+
+	filter := withFuzzyThreshold(models.SongFilter{
+		Group:     entry.Creator,
+		Song:      entry.Title,
+		Match:     "fuzzy",
+		Threshold: options.Threshold,
+	}, s.fuzzyThreshold)
+	songs, _, err := s.songRepo.GetSongsAfter(filter, nil, 0, 1, false)
+	if err != nil {
+		return failed(err)
+	}
+	if len(songs) > 0 {
+		entry.Score = songs[0].Score
+		return matched("fuzzy", songs[0].ID)
+	}
+
+	entry.Status = models.PlaylistEntryUnmatched
+	return entry, options.Create && entry.Creator != ""
+}
+
+// createPlaylistEntry creates the song of an unmatched playlist entry.
+func (s *PlaylistServiceImpl) createPlaylistEntry(entry models.PlaylistImportEntry, audit models.Audit) models.PlaylistImportEntry {
+	song, err := s.songs.CreateSong(models.CreateSongRequest{Group: entry.Creator, Song: entry.Title}, audit)
+	if err != nil {
+		// The artist may be known under an alias the exact match missed.
+		var duplicate *models.DuplicateSongError
+		if errors.As(err, &duplicate) {
+			entry.Status, entry.Match, entry.SongID = models.PlaylistEntryMatched, "exact", duplicate.ExistingID
+			return entry
+		}
+		entry.Status, entry.Err = models.PlaylistEntryFailed, err
+		return entry
+	}
+
+	entry.Status, entry.SongID = models.PlaylistEntryCreated, song.ID
+	return entry
+}
@@ -0,0 +1,142 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/AntonZatsepilin/music-library.git/internal/models"
+	"github.com/AntonZatsepilin/music-library.git/internal/repository"
+	"github.com/sirupsen/logrus"
+)
+
+// ErrPlaylistForbidden is returned when a user accesses a playlist owned by someone else.
+var ErrPlaylistForbidden = fmt.Errorf("%w: playlist belongs to another user", models.ErrForbidden)
+
+type PlaylistServiceImpl struct {
+	repo           repository.PlaylistRepository
+	songRepo       repository.SongRepository
+	songs          SongService
+	fuzzyThreshold float64
+	batchWorkers   int
+}
+
+func NewPlaylistService(repo repository.PlaylistRepository, songRepo repository.SongRepository, songs SongService, fuzzyThreshold float64, batchWorkers int) *PlaylistServiceImpl {
+	return &PlaylistServiceImpl{repo: repo, songRepo: songRepo, songs: songs, fuzzyThreshold: fuzzyThreshold, batchWorkers: batchWorkers}
+}
+
+func (s *PlaylistServiceImpl) CreatePlaylist(owner string, input models.CreatePlaylistRequest) (models.Playlist, error) {
+	name := normalizeName(input.Name)
+	if name == "" {
+		return models.Playlist{}, fmt.Errorf("%w: playlist name must not be empty", models.ErrValidation)
+	}
+
+	id, err := s.repo.CreatePlaylist(owner, name)
+	if err != nil {
+		return models.Playlist{}, err
+	}
+
+	return s.repo.GetPlaylistById(id)
+}
+
+func (s *PlaylistServiceImpl) GetPlaylists(owner string, page, limit int) ([]models.Playlist, int, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 10
+	} else if limit > 100 {
+		limit = 100
+	}
+
+	return s.repo.GetPlaylists(owner, page, limit)
+}
+
+func (s *PlaylistServiceImpl) GetPlaylist(owner string, id int) (models.PlaylistDetail, error) {
+	playlist, err := s.ownedPlaylist(owner, id)
+	if err != nil {
+		return models.PlaylistDetail{}, err
+	}
+
+	entries, err := s.repo.GetPlaylistEntries(id)
+	if err != nil {
+		return models.PlaylistDetail{}, err
+	}
+
+	return models.PlaylistDetail{Playlist: playlist, Entries: entries}, nil
+}
+
+func (s *PlaylistServiceImpl) RenamePlaylist(owner string, id int, input models.UpdatePlaylistRequest) error {
+	name := normalizeName(input.Name)
+	if name == "" {
+		return fmt.Errorf("%w: playlist name must not be empty", models.ErrValidation)
+	}
+
+	if _, err := s.ownedPlaylist(owner, id); err != nil {
+		return err
+	}
+
+	return s.repo.RenamePlaylist(id, name)
+}
+
+func (s *PlaylistServiceImpl) DeletePlaylist(owner string, id int) error {
+	if _, err := s.ownedPlaylist(owner, id); err != nil {
+		return err
+	}
+
+	return s.repo.DeletePlaylistById(id)
+}
+
+func (s *PlaylistServiceImpl) AddPlaylistEntry(owner string, id int, input models.AddPlaylistEntryRequest) (models.PlaylistDetail, error) {
+	if _, err := s.ownedPlaylist(owner, id); err != nil {
+		return models.PlaylistDetail{}, err
+	}
+
+	entryId, err := s.repo.AddPlaylistEntry(id, input.SongID, input.Position)
+	if err != nil {
+		return models.PlaylistDetail{}, err
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"playlistId": id,
+		"entryId":    entryId,
+		"songId":     input.SongID,
+	}).Info("Song added to playlist")
+
+	return s.GetPlaylist(owner, id)
+}
+
+func (s *PlaylistServiceImpl) MovePlaylistEntry(owner string, id, entryId int, input models.MovePlaylistEntryRequest) (models.PlaylistDetail, error) {
+	if input.Position < 1 {
+		return models.PlaylistDetail{}, fmt.Errorf("%w: position must be positive", models.ErrValidation)
+	}
+
+	if _, err := s.ownedPlaylist(owner, id); err != nil {
+		return models.PlaylistDetail{}, err
+	}
+
+	if err := s.repo.MovePlaylistEntry(id, entryId, input.Position); err != nil {
+		return models.PlaylistDetail{}, err
+	}
+
+	return s.GetPlaylist(owner, id)
+}
+
+func (s *PlaylistServiceImpl) RemovePlaylistEntry(owner string, id, entryId int) error {
+	if _, err := s.ownedPlaylist(owner, id); err != nil {
+		return err
+	}
+
+	return s.repo.RemovePlaylistEntry(id, entryId)
+}
+
+func (s *PlaylistServiceImpl) ownedPlaylist(owner string, id int) (models.Playlist, error) {
+	playlist, err := s.repo.GetPlaylistById(id)
+	if err != nil {
+		return playlist, err
+	}
+
+	if playlist.Owner != owner {
+		return playlist, fmt.Errorf("playlist %d: %w", id, ErrPlaylistForbidden)
+	}
+
+	return playlist, nil
+}
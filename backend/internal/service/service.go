@@ -1,27 +1,143 @@
 package service
 
 import (
+	"context"
+	"io"
+	"time"
+
 	"github.com/AntonZatsepilin/music-library.git/internal/models"
 	"github.com/AntonZatsepilin/music-library.git/internal/repository"
 )
 
 type SongService interface {
-	CreateSong(song models.CreateSongRequest) error
-	GenerateFakeSongs(count int) error
-	DeleteSongById(id int) error
-	UpdateSongById(id int, input models.UpdateSongRequest) error
+	CreateSong(song models.CreateSongRequest, audit models.Audit) (models.Song, error)
+	GenerateFakeSongs(count int, audit models.Audit) error
+	DeleteSongById(id, version int, audit models.Audit) error
+	UpdateSongById(id int, input models.UpdateSongRequest, version int, audit models.Audit) (models.Song, error)
+	PatchSongById(id int, input models.PatchSongRequest, version int, audit models.Audit) (models.Song, error)
 	GetSongById(id int) (models.Song, error)
 	GetSongLyrics(songId int, page, limit int) ([]string, int, error)
-	GetSongs(filter models.SongFilter, page, limit int) ([]models.Song, int, error)
+	GetSongs(filter models.SongFilter, page models.SongPageRequest) (models.SongsResponse, error)
+	ExportSongs(ctx context.Context, filter models.SongFilter, each func(models.Song) error) error
+	SearchSongs(query string, page, limit int) ([]models.SongSearchHit, int, error)
+	GetSongDuplicates(threshold float64, page, limit int) ([]models.SongDuplicate, bool, error)
+	MergeSongs(targetId int, input models.MergeSongsRequest, audit models.Audit) (models.MergeSongsResponse, error)
+	GetTrashedSongs(page, limit int) ([]models.Song, int, error)
+	RestoreSong(id int, audit models.Audit) (models.Song, error)
+	PurgeTrash() (int64, error)
+	RunTrashPurge(ctx context.Context, interval time.Duration)
+	GetSongHistory(id, page, limit int) ([]models.SongRevision, int, error)
+	RevertSong(id, revision, version int, audit models.Audit) (models.Song, error)
+	ApplySongBatch(input models.SongBatchRequest, audit models.Audit) ([]models.SongBatchOutcome, error)
+	ImportSongs(body io.Reader, options models.ImportOptions, audit models.Audit) (models.ImportResult, error)
+
+}
+
+type ArtistService interface {
+	CreateArtist(input models.CreateArtistRequest) (models.Artist, error)
+	GetArtists(name string, page, limit int) ([]models.Artist, int, error)
+	GetArtistById(id int) (models.Artist, error)
+	UpdateArtistById(id int, input models.UpdateArtistRequest) error
+	DeleteArtistById(id int) error
+	GetArtistSongs(id int, page, limit int) ([]models.Song, int, error)
+}
+
+type AlbumService interface {
+	CreateAlbum(input models.CreateAlbumRequest) (models.Album, error)
+	GetAlbums(filter models.AlbumFilter, page, limit int) ([]models.Album, int, error)
+	GetAlbumById(id int) (models.Album, error)
+	UpdateAlbumById(id int, input models.UpdateAlbumRequest) error
+	DeleteAlbumById(id int, audit models.Audit) error
+	GetAlbumTracks(id int) ([]models.Song, error)
+	SetAlbumTrack(albumId, songId int, input models.AlbumTrackRequest, audit models.Audit) error
+	RemoveAlbumTrack(albumId, songId int, audit models.Audit) error
+}
+
+type TagService interface {
+	AddSongTags(songId int, tags []string) error
+	RemoveSongTag(songId int, tag string) error
+	GetTagCounts() ([]models.TagCount, error)
+	AddSongGenres(songId int, genres []string) error
+	RemoveSongGenre(songId int, genre string) error
+	GetGenreCounts() ([]models.TagCount, error)
+}
+
+type PlaylistService interface {
+	CreatePlaylist(owner string, input models.CreatePlaylistRequest) (models.Playlist, error)
+	GetPlaylists(owner string, page, limit int) ([]models.Playlist, int, error)
+	GetPlaylist(owner string, id int) (models.PlaylistDetail, error)
+	RenamePlaylist(owner string, id int, input models.UpdatePlaylistRequest) error
+	DeletePlaylist(owner string, id int) error
+	AddPlaylistEntry(owner string, id int, input models.AddPlaylistEntryRequest) (models.PlaylistDetail, error)
+	MovePlaylistEntry(owner string, id, entryId int, input models.MovePlaylistEntryRequest) (models.PlaylistDetail, error)
+	RemovePlaylistEntry(owner string, id, entryId int) error
+	ImportPlaylist(owner string, file models.PlaylistFile, options models.PlaylistImportOptions, audit models.Audit) (models.PlaylistImportResponse, error)
+}
+
+type SmartPlaylistService interface {
+	CreateSmartPlaylist(owner string, input models.SmartPlaylistRequest) (models.SmartPlaylist, error)
+	GetSmartPlaylists(owner string, page, limit int) ([]models.SmartPlaylist, int, error)
+	GetSmartPlaylist(owner string, id int) (models.SmartPlaylist, error)
+	UpdateSmartPlaylist(owner string, id int, input models.SmartPlaylistRequest) (models.SmartPlaylist, error)
+	DeleteSmartPlaylist(owner string, id int) error
+	GetSmartPlaylistSongs(owner string, id int, page, limit int) ([]models.Song, int, error)
+}
+
+type SuggestService interface {
+	Suggest(kind, query string, limit int) ([]models.Suggestion, error)
+	RunSuggestionRefresh(ctx context.Context, interval time.Duration)
+}
+
+type IdempotencyService interface {
+	BeginIdempotentRequest(scope, key, requestHash string) (*models.IdempotentResponse, string, error)
+	HoldIdempotentRequest(ctx context.Context, scope, key, token string)
+	CompleteIdempotentRequest(scope, key, token string, response models.IdempotentResponse) error
+	AbandonIdempotentRequest(scope, key, token string) error
+	RunIdempotencyKeyPurge(ctx context.Context, interval time.Duration)
+}
 
+// Config holds service settings read from the application config.
+type Config struct {
+	// FuzzyThreshold is the similarity a name needs to match with match=fuzzy
+	// when the request does not set its own threshold.
+	FuzzyThreshold float64
+	// IdempotencyTTL is how long responses to requests with an
+	// Idempotency-Key are kept for replay.
+	IdempotencyTTL time.Duration
+	// IdempotencyLease is how long a request holds its Idempotency-Key
+	// before a retry may take over from it.
+	IdempotencyLease time.Duration
+	// TrashRetention is how long deleted songs stay in the trash before
+	// they are purged.
+	TrashRetention time.Duration
+	// BatchSize is the most operations a song batch may hold.
+	BatchSize int
+	// BatchConcurrency is how many songs of a batch, an import or a playlist
+	// import are enriched with the music info API at the same time.
+	BatchConcurrency int
 }
 
 type Service struct {
 	SongService
+	ArtistService
+	AlbumService
+	TagService
+	PlaylistService
+	SmartPlaylistService
+	SuggestService
+	IdempotencyService
 }
 
-func NewService(repos *repository.Repository, infoClient *MusicInfoClient) *Service {
+func NewService(repos *repository.Repository, infoClient *MusicInfoClient, cfg Config) *Service {
+	songService := NewSongService(repos.SongRepository, repos.ArtistRepository, infoClient, cfg)
 	return &Service{
-		SongService: NewSongService(repos.SongRepository, infoClient),
+		SongService:          songService,
+		ArtistService:        NewArtistService(repos.ArtistRepository, repos.SongRepository),
+		AlbumService:         NewAlbumService(repos.AlbumRepository, repos.ArtistRepository),
+		TagService:           NewTagService(repos.TagRepository),
+		PlaylistService:      NewPlaylistService(repos.PlaylistRepository, repos.SongRepository, songService, cfg.FuzzyThreshold, cfg.BatchConcurrency),
+		SmartPlaylistService: NewSmartPlaylistService(repos.SmartPlaylistRepository, repos.SongRepository, cfg.FuzzyThreshold),
+		SuggestService:       NewSuggestService(repos.SuggestRepository),
+		IdempotencyService:   NewIdempotencyService(repos.IdempotencyRepository, cfg.IdempotencyTTL, cfg.IdempotencyLease),
 	}
 }
\ No newline at end of file
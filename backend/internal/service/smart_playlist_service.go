@@ -0,0 +1,173 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/AntonZatsepilin/music-library.git/internal/models"
+	"github.com/AntonZatsepilin/music-library.git/internal/repository"
+)
+
+const (
+	defaultSmartPlaylistSize = 100
+	maxSmartPlaylistSize     = 1000
+)
+
+type SmartPlaylistServiceImpl struct {
+	repo           repository.SmartPlaylistRepository
+	songRepo       repository.SongRepository
+	fuzzyThreshold float64
+}
+
+func NewSmartPlaylistService(repo repository.SmartPlaylistRepository, songRepo repository.SongRepository, fuzzyThreshold float64) *SmartPlaylistServiceImpl {
+	return &SmartPlaylistServiceImpl{
+		repo:           repo,
+		songRepo:       songRepo,
+		fuzzyThreshold: fuzzyThreshold,
+	}
+}
+
+func (s *SmartPlaylistServiceImpl) CreateSmartPlaylist(owner string, input models.SmartPlaylistRequest) (models.SmartPlaylist, error) {
+	playlist, err := smartPlaylistFromRequest(input)
+	if err != nil {
+		return models.SmartPlaylist{}, err
+	}
+	playlist.Owner = owner
+
+	id, err := s.repo.CreateSmartPlaylist(playlist)
+	if err != nil {
+		return models.SmartPlaylist{}, err
+	}
+
+	return s.repo.GetSmartPlaylistById(id)
+}
+
+func (s *SmartPlaylistServiceImpl) GetSmartPlaylists(owner string, page, limit int) ([]models.SmartPlaylist, int, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 10
+	} else if limit > 100 {
+		limit = 100
+	}
+
+	return s.repo.GetSmartPlaylists(owner, page, limit)
+}
+
+func (s *SmartPlaylistServiceImpl) GetSmartPlaylist(owner string, id int) (models.SmartPlaylist, error) {
+	return s.ownedSmartPlaylist(owner, id)
+}
+
+func (s *SmartPlaylistServiceImpl) UpdateSmartPlaylist(owner string, id int, input models.SmartPlaylistRequest) (models.SmartPlaylist, error) {
+	playlist, err := smartPlaylistFromRequest(input)
+	if err != nil {
+		return models.SmartPlaylist{}, err
+	}
+	playlist.ID = id
+
+	if _, err := s.ownedSmartPlaylist(owner, id); err != nil {
+		return models.SmartPlaylist{}, err
+	}
+
+	if err := s.repo.UpdateSmartPlaylist(playlist); err != nil {
+		return models.SmartPlaylist{}, err
+	}
+
+	return s.repo.GetSmartPlaylistById(id)
+}
+
+func (s *SmartPlaylistServiceImpl) DeleteSmartPlaylist(owner string, id int) error {
+	if _, err := s.ownedSmartPlaylist(owner, id); err != nil {
+		return err
+	}
+
+	return s.repo.DeleteSmartPlaylistById(id)
+}
+
+// GetSmartPlaylistSongs evaluates the saved rules against the current library.
+// Pages past the playlist size come back empty.
+func (s *SmartPlaylistServiceImpl) GetSmartPlaylistSongs(owner string, id int, page, limit int) ([]models.Song, int, error) {
+	playlist, err := s.ownedSmartPlaylist(owner, id)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 10
+	} else if limit > 100 {
+		limit = 100
+	}
+
+	offset := (page - 1) * limit
+	if offset >= playlist.MaxSize {
+		total, err := s.countSongs(playlist)
+		return []models.Song{}, total, err
+	}
+
+	songs, total, err := s.songRepo.GetSongs(withFuzzyThreshold(playlist.Rules, s.fuzzyThreshold), page, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if remaining := playlist.MaxSize - offset; len(songs) > remaining {
+		songs = songs[:remaining]
+	}
+	if total > playlist.MaxSize {
+		total = playlist.MaxSize
+	}
+
+	return songs, total, nil
+}
+
+func (s *SmartPlaylistServiceImpl) countSongs(playlist models.SmartPlaylist) (int, error) {
+	_, total, err := s.songRepo.GetSongs(withFuzzyThreshold(playlist.Rules, s.fuzzyThreshold), 1, 1)
+	if err != nil {
+		return 0, err
+	}
+	if total > playlist.MaxSize {
+		total = playlist.MaxSize
+	}
+	return total, nil
+}
+
+func (s *SmartPlaylistServiceImpl) ownedSmartPlaylist(owner string, id int) (models.SmartPlaylist, error) {
+	playlist, err := s.repo.GetSmartPlaylistById(id)
+	if err != nil {
+		return playlist, err
+	}
+
+	if playlist.Owner != owner {
+		return playlist, fmt.Errorf("smart playlist %d: %w", id, ErrPlaylistForbidden)
+	}
+
+	return playlist, nil
+}
+
+func smartPlaylistFromRequest(input models.SmartPlaylistRequest) (models.SmartPlaylist, error) {
+	name := normalizeName(input.Name)
+	if name == "" {
+		return models.SmartPlaylist{}, fmt.Errorf("%w: playlist name must not be empty", models.ErrValidation)
+	}
+
+	maxSize := input.MaxSize
+	if maxSize == 0 {
+		maxSize = defaultSmartPlaylistSize
+	}
+	if maxSize < 1 || maxSize > maxSmartPlaylistSize {
+		return models.SmartPlaylist{}, fmt.Errorf("%w: maxSize must be between 1 and %d", models.ErrValidation, maxSmartPlaylistSize)
+	}
+
+	rules, err := normalizeSongFilter(input.Rules)
+	if err != nil {
+		return models.SmartPlaylist{}, err
+	}
+
+	return models.SmartPlaylist{
+		Name:    name,
+		Rules:   rules,
+		MaxSize: maxSize,
+	}, nil
+}
@@ -0,0 +1,144 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/AntonZatsepilin/music-library.git/internal/models"
+	"github.com/sirupsen/logrus"
+)
+
+// ApplySongBatch creates, updates and deletes songs in one call. Creates are
+// enriched with the music info API several at a time, then the writes are
+// saved in order: in atomic mode in a single transaction, so one failure
+// leaves every other operation not applied, and in items mode one by one.
+// Artists are resolved with the write that names them. The outcomes follow
+// the order of the operations.
+func (s *SongServiceImpl) ApplySongBatch(input models.SongBatchRequest, audit models.Audit) ([]models.SongBatchOutcome, error) {
+	operations := input.Operations
+	if len(operations) == 0 {
+		return nil, fmt.Errorf("%w: operations must not be empty", models.ErrValidation)
+	}
+	if len(operations) > s.batchSize {
+		return nil, fmt.Errorf("%w: a batch holds at most %d operations", models.ErrValidation, s.batchSize)
+	}
+	if !oneOf(input.Mode, "", models.SongBatchAtomic, models.SongBatchItems) {
+		return nil, fmt.Errorf("%w: mode must be atomic or items", models.ErrValidation)
+	}
+
+	outcomes := make([]models.SongBatchOutcome, len(operations))
+	writes := make([]models.SongWrite, len(operations))
+	forEachConcurrently(len(operations), s.batchWorkers, func(i int) {
+		outcomes[i].Op, outcomes[i].ID = operations[i].Op, operations[i].ID
+		writes[i], outcomes[i].Err = s.prepareSongWrite(operations[i])
+	})
+
+	if input.Mode == models.SongBatchItems {
+		for i, write := range writes {
+			if outcomes[i].Err != nil {
+				continue
+			}
+			songs, err := s.repo.ApplySongWrites([]models.SongWrite{write}, audit)
+			var writeErr *models.SongWriteError
+			if errors.As(err, &writeErr) {
+				err = writeErr.Err
+			}
+			if err != nil {
+				outcomes[i].Err = err
+				continue
+			}
+			outcomes[i].ID, outcomes[i].Version = songs[0].ID, songs[0].Version
+		}
+		return outcomes, nil
+	}
+
+	for _, outcome := range outcomes {
+		if outcome.Err != nil {
+			return notApplied(outcomes), nil
+		}
+	}
+
+	songs, err := s.repo.ApplySongWrites(writes, audit)
+	if err != nil {
+		logrus.WithError(err).Warn("Song batch rolled back")
+		var writeErr *models.SongWriteError
+		if !errors.As(err, &writeErr) {
+			return nil, err
+		}
+		outcomes[writeErr.Index].Err = writeErr.Err
+		return notApplied(outcomes), nil
+	}
+
+	for i, song := range songs {
+		outcomes[i].ID, outcomes[i].Version = song.ID, song.Version
+	}
+	return outcomes, nil
+}
+
+// prepareSongWrite validates a batch operation and does the lookups it needs
+// before anything is saved. The artist is left to be resolved when the write
+// is saved.
+func (s *SongServiceImpl) prepareSongWrite(operation models.SongBatchOperation) (models.SongWrite, error) {
+	write := models.SongWrite{Op: operation.Op, ID: operation.ID, Version: operation.Version}
+
+	var err error
+	switch operation.Op {
+	case "create":
+		write.Song, err = s.songDetails(models.CreateSongRequest{Group: operation.Group, Song: operation.Song})
+		write.Group = write.Song.Group
+	case "update", "delete":
+		if operation.ID <= 0 {
+			return write, fmt.Errorf("%w: %s needs the id of a song", models.ErrValidation, operation.Op)
+		}
+		if operation.Op == "update" {
+			write.Group, write.Changes, err = replacementFields(models.UpdateSongRequest{
+				Group:       operation.Group,
+				Song:        operation.Song,
+				ReleaseDate: operation.ReleaseDate,
+				Text:        operation.Text,
+				Link:        operation.Link,
+			}, operation.Version)
+		}
+	default:
+		err = fmt.Errorf("%w: op must be create, update or delete", models.ErrValidation)
+	}
+	return write, err
+}
+
+// forEachConcurrently calls f for 0..n-1 with at most workers calls running
+// at a time, and returns when all of them are done.
+func forEachConcurrently(n, workers int, f func(i int)) {
+	if workers > n {
+		workers = n
+	}
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				f(i)
+			}
+		}()
+	}
+
+	for i := 0; i < n; i++ {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+}
+
+// notApplied marks the operations that did not fail themselves as rolled back
+// with the rest of an atomic batch.
+func notApplied(outcomes []models.SongBatchOutcome) []models.SongBatchOutcome {
+	for i := range outcomes {
+		if outcomes[i].Err == nil {
+			outcomes[i].Err = models.ErrNotApplied
+		}
+	}
+	return outcomes
+}
@@ -0,0 +1,369 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/AntonZatsepilin/music-library.git/internal/models"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	maxImportLineSize = 1 << 20
+	maxImportFailures = 1000
+	maxImportPreview  = 20
+)
+
+// importFields are the song fields an import reads, in their default CSV order.
+var importFields = []string{"group", "song", "releaseDate", "text", "link"}
+
+// importLine is the song read from a line of an import, or why it could not
+// be read.
+type importLine struct {
+	line int
+	song models.ImportSong
+	err  error
+}
+
+// importReader reads an import one song at a time. next returns io.EOF after
+// the last song; any other error means the rest of the input is unreadable.
+type importReader interface {
+	next() (importLine, error)
+}
+
+// ImportSongs reads songs from a CSV or NDJSON stream and saves them. Lines
+// are read a chunk at a time; the songs of a chunk are checked and enriched
+// concurrently and saved one by one in input order, so a failed line does
+// not stop the import. An unreadable input stops it, keeping the songs saved
+// so far. A dry run checks every line, including for songs that already
+// exist or repeat an earlier line, without saving anything.
+func (s *SongServiceImpl) ImportSongs(body io.Reader, options models.ImportOptions, audit models.Audit) (models.ImportResult, error) {
+	var (
+		reader importReader
+		err    error
+	)
+	switch options.Format {
+	case models.ImportCSV:
+		reader, err = newCSVImportReader(body, options)
+	case models.ImportNDJSON:
+		reader = newNDJSONImportReader(body)
+	default:
+		err = fmt.Errorf("%w: format must be csv or ndjson", models.ErrValidation)
+	}
+	if err != nil {
+		return models.ImportResult{}, err
+	}
+
+	importer := &songImporter{
+		service: s,
+		options: options,
+		audit:   audit,
+		result:  models.ImportResult{DryRun: options.DryRun, Failures: []models.ImportFailure{}},
+		seen:    map[string]int{},
+	}
+
+	chunk := make([]importLine, 0, s.batchSize)
+	for {
+		line, err := reader.next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			logrus.WithError(err).WithField("line", line.line).Warn("Import stopped on unreadable input")
+			importer.flush(chunk)
+			importer.fail(line.line, err)
+			return importer.result, nil
+		}
+
+		importer.result.Lines++
+		chunk = append(chunk, line)
+		if len(chunk) == cap(chunk) {
+			importer.flush(chunk)
+			chunk = chunk[:0]
+		}
+	}
+	importer.flush(chunk)
+
+	logrus.WithFields(logrus.Fields{
+		"lines":    importer.result.Lines,
+		"imported": importer.result.Imported,
+		"failed":   importer.result.Failed,
+		"dryRun":   options.DryRun,
+	}).Info("Songs imported")
+	return importer.result, nil
+}
+
+// songImporter keeps the state of an import between chunks.
+type songImporter struct {
+	service *SongServiceImpl
+	options models.ImportOptions
+	audit   models.Audit
+	result  models.ImportResult
+	// Lines of the songs a dry run has accepted, by artist and song name
+	seen map[string]int
+}
+
+func (imp *songImporter) flush(chunk []importLine) {
+	songs := make([]models.Song, len(chunk))
+	errs := make([]error, len(chunk))
+	forEachConcurrently(len(chunk), imp.service.batchWorkers, func(i int) {
+		if errs[i] = chunk[i].err; errs[i] == nil {
+			songs[i], errs[i] = imp.service.prepareImportSong(chunk[i].song, imp.options)
+		}
+	})
+
+	for i, line := range chunk {
+		if errs[i] != nil {
+			imp.fail(line.line, errs[i])
+			continue
+		}
+		imp.save(line.line, songs[i])
+	}
+}
+
+func (imp *songImporter) save(line int, song models.Song) {
+	if imp.options.DryRun {
+		key := strings.ToLower(song.Group) + "\x00" + strings.ToLower(normalizeName(song.SongName))
+		if first, ok := imp.seen[key]; ok {
+			imp.fail(line, fmt.Errorf("%w: song repeats line %d", models.ErrConflict, first))
+			return
+		}
+		imp.seen[key] = line
+
+		imp.result.Imported++
+		if len(imp.result.Preview) < maxImportPreview {
+			imp.result.Preview = append(imp.result.Preview, song)
+		}
+		return
+	}
+
+	if _, err := imp.service.repo.CreateSong(song, imp.audit); err != nil {
+		imp.fail(line, err)
+		return
+	}
+	imp.result.Imported++
+}
+
+func (imp *songImporter) fail(line int, err error) {
+	imp.result.Failed++
+	if len(imp.result.Failures) < maxImportFailures {
+		imp.result.Failures = append(imp.result.Failures, models.ImportFailure{Line: line, Err: err})
+	}
+}
+
+// prepareImportSong validates an imported song, fills in its empty fields
+// from the music info API when asked to and resolves its artist. A dry run
+// looks up an existing song of the same name instead of resolving the artist,
+// which could create it.
+func (s *SongServiceImpl) prepareImportSong(input models.ImportSong, options models.ImportOptions) (models.Song, error) {
+	song := models.Song{
+		Group:    normalizeName(input.Group),
+		SongName: strings.TrimSpace(input.Song),
+		Text:     input.Text,
+		Link:     input.Link,
+	}
+	if song.Group == "" || song.SongName == "" {
+		return song, fmt.Errorf("%w: group and song must not be empty", models.ErrValidation)
+	}
+
+	var err error
+	if song.ReleaseDate, err = models.ParseReleaseDate(input.ReleaseDate); err != nil {
+		return song, err
+	}
+
+	if options.Enrich && (song.ReleaseDate == "" || song.Text == "" || song.Link == "") {
+		detail, err := s.infoClient.GetSongDetail(song.Group, song.SongName)
+		if err != nil {
+			return song, fmt.Errorf("API error: %w", err)
+		}
+		if song.ReleaseDate == "" {
+			if song.ReleaseDate, err = models.ParseReleaseDate(detail.ReleaseDate); err != nil {
+				return song, fmt.Errorf("API error: %w: %v", models.ErrUpstreamUnavailable, err)
+			}
+		}
+		if song.Text == "" {
+			song.Text = detail.Text
+		}
+		if song.Link == "" {
+			song.Link = detail.Link
+		}
+	}
+
+	if options.DryRun {
+		existingId, err := s.repo.FindSongId(song.Group, song.SongName)
+		if err != nil {
+			return song, err
+		}
+		if existingId != 0 {
+			return song, &models.DuplicateSongError{ExistingID: existingId}
+		}
+		return song, nil
+	}
+
+	song.ArtistID, err = s.artistRepo.ResolveArtist(song.Group)
+	return song, err
+}
+
+type csvImportReader struct {
+	reader *csv.Reader
+	// Record index of each of importFields; -1 when the CSV lacks it
+	columns  []int
+	lastLine int
+}
+
+func newCSVImportReader(body io.Reader, options models.ImportOptions) (*csvImportReader, error) {
+	if options.Delimiter == "" {
+		options.Delimiter = ","
+	}
+	delimiter, size := utf8.DecodeRuneInString(options.Delimiter)
+	if size == 0 || size != len(options.Delimiter) || delimiter == '"' || delimiter == '\r' || delimiter == '\n' || delimiter == utf8.RuneError {
+		return nil, fmt.Errorf("%w: delimiter must be a single character other than a quote or line break", models.ErrValidation)
+	}
+
+	r := &csvImportReader{reader: csv.NewReader(skipBOM(body))}
+	r.reader.Comma = delimiter
+	r.reader.FieldsPerRecord = -1
+	r.reader.ReuseRecord = true
+
+	var header []string
+	if options.Header {
+		record, err := r.reader.Read()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil, fmt.Errorf("%w: the CSV has no header line", models.ErrValidation)
+			}
+			return nil, fmt.Errorf("%w: invalid CSV header: %v", models.ErrValidation, err)
+		}
+		header = append(header, record...)
+		r.lastLine, _ = r.reader.FieldPos(0)
+	}
+
+	mapping := []string{options.Group, options.Song, options.ReleaseDate, options.Text, options.Link}
+	for i, column := range mapping {
+		index, err := csvColumn(strings.TrimSpace(column), i, header)
+		if err != nil {
+			return nil, err
+		}
+		r.columns = append(r.columns, index)
+	}
+
+	return r, nil
+}
+
+// csvColumn finds the record index of importFields[field]. column is a header
+// name or a 1-based position; empty for the default. Only group and song are
+// required to have a column.
+func csvColumn(column string, field int, header []string) (int, error) {
+	if position, err := strconv.Atoi(column); err == nil {
+		if position < 1 {
+			return -1, fmt.Errorf("%w: column positions start at 1", models.ErrValidation)
+		}
+		return position - 1, nil
+	}
+
+	if header == nil {
+		if column != "" {
+			return -1, fmt.Errorf("%w: without a header the column of %s must be a position", models.ErrValidation, importFields[field])
+		}
+		return field, nil
+	}
+
+	name := column
+	if name == "" {
+		name = importFields[field]
+	}
+	for i, title := range header {
+		if strings.EqualFold(strings.TrimSpace(title), name) {
+			return i, nil
+		}
+	}
+
+	if column == "" && field > 1 {
+		return -1, nil
+	}
+	return -1, fmt.Errorf("%w: the CSV has no %q column for %s", models.ErrValidation, name, importFields[field])
+}
+
+func (r *csvImportReader) next() (importLine, error) {
+	record, err := r.reader.Read()
+	if err != nil {
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			r.lastLine = parseErr.Line
+			return importLine{line: parseErr.StartLine, err: fmt.Errorf("%w: %v", models.ErrValidation, parseErr.Err)}, nil
+		}
+		return importLine{line: r.lastLine + 1}, err
+	}
+
+	line, _ := r.reader.FieldPos(0)
+	r.lastLine = line
+
+	field := func(i int) string {
+		if index := r.columns[i]; index >= 0 && index < len(record) {
+			return record[index]
+		}
+		return ""
+	}
+
+	return importLine{line: line, song: models.ImportSong{
+		Group:       field(0),
+		Song:        field(1),
+		ReleaseDate: field(2),
+		Text:        field(3),
+		Link:        field(4),
+	}}, nil
+}
+
+type ndjsonImportReader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+func newNDJSONImportReader(body io.Reader) *ndjsonImportReader {
+	scanner := bufio.NewScanner(skipBOM(body))
+	scanner.Buffer(make([]byte, 0, 64*1024), maxImportLineSize)
+	return &ndjsonImportReader{scanner: scanner}
+}
+
+func (r *ndjsonImportReader) next() (importLine, error) {
+	for r.scanner.Scan() {
+		r.line++
+		data := bytes.TrimSpace(r.scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+
+		line := importLine{line: r.line}
+		if err := json.Unmarshal(data, &line.song); err != nil {
+			line.err = fmt.Errorf("%w: %v", models.ErrValidation, err)
+		}
+		return line, nil
+	}
+
+	err := r.scanner.Err()
+	if err == nil {
+		return importLine{}, io.EOF
+	}
+	if errors.Is(err, bufio.ErrTooLong) {
+		err = fmt.Errorf("%w: line is longer than %d bytes", models.ErrValidation, maxImportLineSize)
+	}
+	return importLine{line: r.line + 1}, err
+}
+
+// skipBOM drops the UTF-8 byte order mark spreadsheets and editors often start
+// files with.
+func skipBOM(body io.Reader) io.Reader {
+	buffered := bufio.NewReader(body)
+	if bom, _ := buffered.Peek(3); bytes.Equal(bom, []byte("\ufeff")) {
+		buffered.Discard(3)
+	}
+	return buffered
+}
@@ -0,0 +1,285 @@
+package service
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/AntonZatsepilin/music-library.git/internal/models"
+)
+
+// readImport reads every line of an import and the error that stopped it,
+// nil when the input ended.
+func readImport(t *testing.T, reader importReader) ([]importLine, error) {
+	t.Helper()
+
+	var lines []importLine
+	for {
+		line, err := reader.next()
+		if errors.Is(err, io.EOF) {
+			return lines, nil
+		}
+		if err != nil {
+			return lines, err
+		}
+		lines = append(lines, line)
+	}
+}
+
+// importSongLine is a line read without error.
+func importSongLine(line int, group, song string) importLine {
+	return importLine{line: line, song: models.ImportSong{Group: group, Song: song}}
+}
+
+// checkImportLines compares the lines read with want. Lines that failed only
+// have their line number and ErrValidation checked.
+func checkImportLines(t *testing.T, got, want []importLine) {
+	t.Helper()
+
+	if len(got) != len(want) {
+		t.Fatalf("read %d lines %+v, want %d lines %+v", len(got), got, len(want), want)
+	}
+	for i := range want {
+		if want[i].err != nil {
+			if got[i].line != want[i].line || !errors.Is(got[i].err, models.ErrValidation) {
+				t.Errorf("line %d = %+v, want a validation error on line %d", i, got[i], want[i].line)
+			}
+			continue
+		}
+		if !reflect.DeepEqual(got[i], want[i]) {
+			t.Errorf("line %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestCSVImportReader(t *testing.T) {
+	invalid := func(line int) importLine { return importLine{line: line, err: models.ErrValidation} }
+
+	tests := []struct {
+		name    string
+		input   string
+		options models.ImportOptions
+		want    []importLine
+	}{
+		{
+			name:    "header",
+			input:   "group,song\nMuse,Hysteria\nQueen,Bohemian Rhapsody\n",
+			options: models.ImportOptions{Header: true},
+			want:    []importLine{importSongLine(2, "Muse", "Hysteria"), importSongLine(3, "Queen", "Bohemian Rhapsody")},
+		},
+		{
+			name:    "byte order mark and CRLF",
+			input:   "\ufeffgroup,song\r\nMuse,Hysteria\r\n",
+			options: models.ImportOptions{Header: true},
+			want:    []importLine{importSongLine(2, "Muse", "Hysteria")},
+		},
+		{
+			name:    "byte order mark without header",
+			input:   "\ufeffMuse,Hysteria",
+			options: models.ImportOptions{},
+			want:    []importLine{importSongLine(1, "Muse", "Hysteria")},
+		},
+		{
+			name:    "all fields in header order",
+			input:   "link,text,releaseDate,song,group\nhttps://example.com,la la,16.07.2006,Hysteria,Muse\n",
+			options: models.ImportOptions{Header: true},
+			want: []importLine{{line: 2, song: models.ImportSong{
+				Group: "Muse", Song: "Hysteria", ReleaseDate: "16.07.2006", Text: "la la", Link: "https://example.com",
+			}}},
+		},
+		{
+			name:    "quoted fields",
+			input:   "group,song,text\n\"Muse, the band\",\"Hysteria\",\"first line\r\nsaid \"\"hi\"\"\"\nQueen,Bohemian Rhapsody,\n",
+			options: models.ImportOptions{Header: true},
+			want: []importLine{
+				{line: 2, song: models.ImportSong{Group: "Muse, the band", Song: "Hysteria", Text: "first line\nsaid \"hi\""}},
+				importSongLine(4, "Queen", "Bohemian Rhapsody"),
+			},
+		},
+		{
+			name:    "columns by name",
+			input:   "Title; Artist \nHysteria;Muse\n",
+			options: models.ImportOptions{Header: true, Delimiter: ";", Group: "artist", Song: " TITLE "},
+			want:    []importLine{importSongLine(2, "Muse", "Hysteria")},
+		},
+		{
+			name:    "columns by position without header",
+			input:   "Hysteria\tMuse\n",
+			options: models.ImportOptions{Delimiter: "\t", Group: "2", Song: "1"},
+			want:    []importLine{importSongLine(1, "Muse", "Hysteria")},
+		},
+		{
+			name:    "short and long records",
+			input:   "group,song\nMuse\nQueen,Bohemian Rhapsody,extra\n",
+			options: models.ImportOptions{Header: true},
+			want:    []importLine{importSongLine(2, "Muse", ""), importSongLine(3, "Queen", "Bohemian Rhapsody")},
+		},
+		{
+			name:    "bare quote",
+			input:   "group,song\nMu\"se,Hysteria\nQueen,Bohemian Rhapsody\n",
+			options: models.ImportOptions{Header: true},
+			want:    []importLine{invalid(2), importSongLine(3, "Queen", "Bohemian Rhapsody")},
+		},
+		{
+			name:    "unterminated quote",
+			input:   "group,song\n\"Muse,Hysteria\nQueen,Bohemian Rhapsody\n",
+			options: models.ImportOptions{Header: true},
+			want:    []importLine{invalid(2)},
+		},
+		{
+			name:    "header only",
+			input:   "group,song\n",
+			options: models.ImportOptions{Header: true},
+			want:    nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader, err := newCSVImportReader(strings.NewReader(tt.input), tt.options)
+			if err != nil {
+				t.Fatalf("newCSVImportReader() unexpected error: %v", err)
+			}
+
+			lines, err := readImport(t, reader)
+			if err != nil {
+				t.Fatalf("reading the import stopped with %v", err)
+			}
+			checkImportLines(t, lines, tt.want)
+		})
+	}
+}
+
+func TestNewCSVImportReaderErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		options models.ImportOptions
+	}{
+		{name: "empty with header", input: "", options: models.ImportOptions{Header: true}},
+		{name: "invalid header", input: "\"group,song\n", options: models.ImportOptions{Header: true}},
+		{name: "missing song column", input: "group,title\n", options: models.ImportOptions{Header: true}},
+		{name: "unknown named column", input: "group,song\n", options: models.ImportOptions{Header: true, Link: "url"}},
+		{name: "name without header", input: "", options: models.ImportOptions{Group: "artist"}},
+		{name: "zero position", input: "", options: models.ImportOptions{Group: "0"}},
+		{name: "quote delimiter", input: "", options: models.ImportOptions{Delimiter: `"`}},
+		{name: "line break delimiter", input: "", options: models.ImportOptions{Delimiter: "\n"}},
+		{name: "long delimiter", input: "", options: models.ImportOptions{Delimiter: ";;"}},
+		{name: "invalid delimiter", input: "", options: models.ImportOptions{Delimiter: "\xff"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := newCSVImportReader(strings.NewReader(tt.input), tt.options); !errors.Is(err, models.ErrValidation) {
+				t.Errorf("newCSVImportReader() error = %v, want ErrValidation", err)
+			}
+		})
+	}
+}
+
+func TestCSVColumn(t *testing.T) {
+	header := []string{"Artist", " song ", "releaseDate", "Lyrics"}
+
+	tests := []struct {
+		name    string
+		column  string
+		field   int
+		header  []string
+		want    int
+		wantErr bool
+	}{
+		{name: "position", column: "3", field: 0, header: header, want: 2},
+		{name: "position without header", column: "1", field: 1, want: 0},
+		{name: "zero position", column: "0", field: 0, header: header, wantErr: true},
+		{name: "negative position", column: "-2", field: 0, wantErr: true},
+		{name: "default without header", column: "", field: 4, want: 4},
+		{name: "name without header", column: "artist", field: 0, wantErr: true},
+		{name: "name", column: "artist", field: 0, header: header, want: 0},
+		{name: "default name", column: "", field: 1, header: header, want: 1},
+		{name: "default name case", column: "", field: 2, header: header, want: 2},
+		{name: "missing required default", column: "", field: 0, header: header, wantErr: true},
+		{name: "missing optional default", column: "", field: 4, header: header, want: -1},
+		{name: "missing optional name", column: "url", field: 4, header: header, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := csvColumn(tt.column, tt.field, tt.header)
+			if tt.wantErr {
+				if !errors.Is(err, models.ErrValidation) {
+					t.Fatalf("csvColumn(%q, %d) error = %v, want ErrValidation", tt.column, tt.field, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("csvColumn(%q, %d) unexpected error: %v", tt.column, tt.field, err)
+			}
+			if got != tt.want {
+				t.Errorf("csvColumn(%q, %d) = %d, want %d", tt.column, tt.field, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNDJSONImportReader(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		want     []importLine
+		wantStop bool
+	}{
+		{
+			name:  "lines",
+			input: `{"group":"Muse","song":"Hysteria"}` + "\n" + `{"group":"Queen","song":"Bohemian Rhapsody"}`,
+			want:  []importLine{importSongLine(1, "Muse", "Hysteria"), importSongLine(2, "Queen", "Bohemian Rhapsody")},
+		},
+		{
+			name:  "byte order mark, CRLF and blank lines",
+			input: "\ufeff" + `{"group":"Muse","song":"Hysteria"}` + "\r\n\r\n  \r\n" + `{"group":"Queen","song":"Bohemian Rhapsody"}` + "\r\n",
+			want:  []importLine{importSongLine(1, "Muse", "Hysteria"), importSongLine(4, "Queen", "Bohemian Rhapsody")},
+		},
+		{
+			name:  "all fields",
+			input: `{"group":"Muse","song":"Hysteria","releaseDate":"2003-12-01","text":"It's bugging me\n","link":"https://example.com","extra":1}`,
+			want: []importLine{{line: 1, song: models.ImportSong{
+				Group: "Muse", Song: "Hysteria", ReleaseDate: "2003-12-01", Text: "It's bugging me\n", Link: "https://example.com",
+			}}},
+		},
+		{
+			name:  "malformed lines",
+			input: "group,song\n" + `{"group":"Muse"` + "\n" + `{"group":1}` + "\n" + `{"group":"Queen","song":"Bohemian Rhapsody"}`,
+			want: []importLine{
+				{line: 1, err: models.ErrValidation},
+				{line: 2, err: models.ErrValidation},
+				{line: 3, err: models.ErrValidation},
+				importSongLine(4, "Queen", "Bohemian Rhapsody"),
+			},
+		},
+		{
+			name:     "line too long",
+			input:    `{"group":"Muse","song":"Hysteria"}` + "\n" + `{"text":"` + strings.Repeat("a", maxImportLineSize) + `"}`,
+			want:     []importLine{importSongLine(1, "Muse", "Hysteria")},
+			wantStop: true,
+		},
+		{
+			name:  "empty",
+			input: "",
+			want:  nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines, err := readImport(t, newNDJSONImportReader(strings.NewReader(tt.input)))
+			if tt.wantStop != (err != nil) {
+				t.Fatalf("reading the import stopped with %v, want stop %v", err, tt.wantStop)
+			}
+			if tt.wantStop && !errors.Is(err, models.ErrValidation) {
+				t.Errorf("reading the import stopped with %v, want ErrValidation", err)
+			}
+			checkImportLines(t, lines, tt.want)
+		})
+	}
+}
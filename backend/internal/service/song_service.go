@@ -1,10 +1,13 @@
 package service
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"math/rand"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/AntonZatsepilin/music-library.git/internal/models"
 	"github.com/AntonZatsepilin/music-library.git/internal/repository"
@@ -14,19 +17,78 @@ import (
 
 
 type SongServiceImpl struct {
-    repo       repository.SongRepository
-    infoClient *MusicInfoClient
+    repo           repository.SongRepository
+    artistRepo     repository.ArtistRepository
+    infoClient     *MusicInfoClient
+    fuzzyThreshold float64
+    trashRetention time.Duration
+    batchSize      int
+    batchWorkers   int
 }
 
-func NewSongService(repo repository.SongRepository, infoClient *MusicInfoClient) *SongServiceImpl {
+const (
+    defaultTrashRetention     = 30 * 24 * time.Hour
+    defaultTrashPurgeInterval = time.Hour
+    defaultBatchSize          = 100
+    defaultBatchConcurrency   = 4
+)
+
+func NewSongService(repo repository.SongRepository, artistRepo repository.ArtistRepository, infoClient *MusicInfoClient, cfg Config) *SongServiceImpl {
+    if cfg.TrashRetention <= 0 {
+        cfg.TrashRetention = defaultTrashRetention
+    }
+    if cfg.BatchSize <= 0 {
+        cfg.BatchSize = defaultBatchSize
+    }
+    if cfg.BatchConcurrency <= 0 {
+        cfg.BatchConcurrency = defaultBatchConcurrency
+    }
     return &SongServiceImpl{
-        repo:       repo,
-        infoClient: infoClient,
+        repo:           repo,
+        artistRepo:     artistRepo,
+        infoClient:     infoClient,
+        fuzzyThreshold: cfg.FuzzyThreshold,
+        trashRetention: cfg.TrashRetention,
+        batchSize:      cfg.BatchSize,
+        batchWorkers:   cfg.BatchConcurrency,
     }
 }
 
 
-func (s *SongServiceImpl) CreateSong(input models.CreateSongRequest) error {
+// CreateSong enriches the song with the music info API and stores it.
+func (s *SongServiceImpl) CreateSong(input models.CreateSongRequest, audit models.Audit) (models.Song, error) {
+    song, err := s.enrichSong(input)
+    if err != nil {
+        return models.Song{}, err
+    }
+
+    logrus.Debug("Saving a song to the database")
+    return s.repo.CreateSong(song, audit)
+}
+
+// enrichSong builds a new song from the music info API details and resolves
+// its artist.
+func (s *SongServiceImpl) enrichSong(input models.CreateSongRequest) (models.Song, error) {
+    song, err := s.songDetails(input)
+    if err != nil {
+        return models.Song{}, err
+    }
+
+    song.ArtistID, err = s.artistRepo.ResolveArtist(song.Group)
+    if err != nil {
+        return models.Song{}, err
+    }
+    return song, nil
+}
+
+// songDetails builds a new song from the music info API details. The artist
+// is only named by Group and not resolved yet.
+func (s *SongServiceImpl) songDetails(input models.CreateSongRequest) (models.Song, error) {
+    group := normalizeName(input.Group)
+    if group == "" {
+        return models.Song{}, fmt.Errorf("%w: group must not be empty", models.ErrValidation)
+    }
+
     logrus.WithFields(logrus.Fields{
         "group": input.Group,
         "song":  input.Song,
@@ -34,49 +96,229 @@ func (s *SongServiceImpl) CreateSong(input models.CreateSongRequest) error {
     detail, err := s.infoClient.GetSongDetail(input.Group, input.Song)
     if err != nil {
         logrus.WithError(err).Error("API error")
-        return fmt.Errorf("API error: %w", err)
+        return models.Song{}, fmt.Errorf("API error: %w", err)
         }
 
-    song := models.Song{
-        Group:       input.Group,
+    releaseDate, err := models.ParseReleaseDate(detail.ReleaseDate)
+    if err != nil {
+        logrus.WithError(err).Error("API returned an unparseable release date")
+        return models.Song{}, fmt.Errorf("API error: %w: %v", models.ErrUpstreamUnavailable, err)
+    }
+
+    return models.Song{
+        Group:       group,
         SongName:    input.Song,
-        ReleaseDate: detail.ReleaseDate,
+        ReleaseDate: releaseDate,
         Text:        detail.Text,
         Link:        detail.Link,
-    }
-    logrus.Debug("Saving a song to the database")
-    return s.repo.CreateSong(song)
+    }, nil
 }
 
-func (s *SongServiceImpl) GenerateFakeSongs(count int) error {
+func (s *SongServiceImpl) GenerateFakeSongs(count int, audit models.Audit) error {
     rand.Seed(time.Now().UnixNano())
     
     for i := 0; i < count; i++ {
+        if err := s.generateFakeSong(audit); err != nil {
+            return fmt.Errorf("failed to generate song: %w", err)
+        }
+    }
+    return nil
+}
+
+// maxFakeSongAttempts is how many random songs are tried before giving up on
+// one that does not collide with an existing song.
+const maxFakeSongAttempts = 10
+
+func (s *SongServiceImpl) generateFakeSong(audit models.Audit) error {
+    var err error
+    for attempt := 0; attempt < maxFakeSongAttempts; attempt++ {
         year := 1990 + rand.Intn(34)
         month := rand.Intn(12) + 1
         day := rand.Intn(28) + 1
         
+        group := fmt.Sprintf("%s %s", faker.FirstName(), faker.LastName())
+        var artistId int
+        if artistId, err = s.artistRepo.ResolveArtist(group); err != nil {
+            return err
+        }
+
         song := models.Song{
-            Group:       fmt.Sprintf("%s %s", faker.FirstName(), faker.LastName()),
+            ArtistID:    artistId,
+            Group:       group,
             SongName:    faker.Word(),
             ReleaseDate: fmt.Sprintf("%d-%02d-%02d", year, month, day),
             Text:        faker.Paragraph(),
             Link:        fmt.Sprintf("https://example.com/%s", faker.UUIDDigit()),
         }
         
-        if err := s.repo.CreateSong(song); err != nil {
-            return fmt.Errorf("failed to generate song: %w", err)
+        // Random names collide now and then; generate another song instead.
+        if _, err = s.repo.CreateSong(song, audit); !errors.Is(err, models.ErrConflict) {
+            return err
         }
     }
-    return nil
+    return err
+}
+
+// DeleteSongById moves the song to the trash. A non-zero version makes the
+// deletion conditional on the song still being at that version.
+func (s *SongServiceImpl) DeleteSongById(id, version int, audit models.Audit) error {
+    return s.repo.DeleteSongById(id, version, audit)
+}
+
+func (s *SongServiceImpl) GetTrashedSongs(page, limit int) ([]models.Song, int, error) {
+    return s.repo.GetTrashedSongs(page, limit)
+}
+
+func (s *SongServiceImpl) RestoreSong(id int, audit models.Audit) (models.Song, error) {
+    return s.repo.RestoreSong(id, audit)
 }
 
-func (s *SongServiceImpl) DeleteSongById(id int) error {
-    return s.repo.DeleteSongById(id)
+// PurgeTrash permanently removes the songs that have been in the trash for
+// longer than the retention period.
+func (s *SongServiceImpl) PurgeTrash() (int64, error) {
+    return s.repo.PurgeDeletedSongs(time.Now().Add(-s.trashRetention))
 }
 
-func (s *SongServiceImpl) UpdateSongById(id int, input models.UpdateSongRequest) error {
-    return s.repo.UpdateSongById(id, input)
+// RunTrashPurge purges the trash every interval until ctx is done.
+func (s *SongServiceImpl) RunTrashPurge(ctx context.Context, interval time.Duration) {
+    if interval <= 0 {
+        interval = defaultTrashPurgeInterval
+    }
+    ticker := time.NewTicker(interval)
+    defer ticker.Stop()
+
+    for {
+        purged, err := s.PurgeTrash()
+        if err != nil {
+            logrus.WithError(err).Error("Trash purge failed")
+        } else if purged > 0 {
+            logrus.WithField("count", purged).Info("Purged songs from the trash")
+        }
+
+        select {
+        case <-ctx.Done():
+            return
+        case <-ticker.C:
+        }
+    }
+}
+
+// UpdateSongById replaces all editable fields of the song with input. A
+// non-zero version makes the update conditional on the song's version.
+func (s *SongServiceImpl) UpdateSongById(id int, input models.UpdateSongRequest, version int, audit models.Audit) (models.Song, error) {
+    changes, err := s.replacementChanges(input, version)
+    if err != nil {
+        return models.Song{}, err
+    }
+
+    return s.repo.UpdateSongById(id, changes, audit)
+}
+
+// replacementChanges validates a full replacement of a song and resolves its
+// artist.
+func (s *SongServiceImpl) replacementChanges(input models.UpdateSongRequest, version int) (models.SongChanges, error) {
+    group, changes, err := replacementFields(input, version)
+    if err != nil {
+        return models.SongChanges{}, err
+    }
+
+    artistId, err := s.artistRepo.ResolveArtist(group)
+    if err != nil {
+        return models.SongChanges{}, err
+    }
+    changes.ArtistID = &artistId
+    return changes, nil
+}
+
+// replacementFields validates a full replacement of a song and returns the
+// normalized name of its artist along with the changes, which leave the
+// artist unset.
+func replacementFields(input models.UpdateSongRequest, version int) (string, models.SongChanges, error) {
+    group := normalizeName(input.Group)
+    if group == "" || strings.TrimSpace(input.Song) == "" {
+        return "", models.SongChanges{}, fmt.Errorf("%w: group and song must not be empty", models.ErrValidation)
+    }
+
+    releaseDate, err := models.ParseReleaseDate(input.ReleaseDate)
+    if err != nil {
+        return "", models.SongChanges{}, err
+    }
+
+    return group, models.SongChanges{
+        SongName:    &input.Song,
+        ReleaseDate: &releaseDate,
+        Text:        &input.Text,
+        Link:        &input.Link,
+        Version:     version,
+    }, nil
+}
+
+// PatchSongById applies a JSON Merge Patch to the song. Group and song name
+// cannot be cleared; null clears the release date, text and link.
+func (s *SongServiceImpl) PatchSongById(id int, input models.PatchSongRequest, version int, audit models.Audit) (models.Song, error) {
+    changes := models.SongChanges{Version: version}
+
+    if input.Group.Set {
+        group := normalizeName(input.Group.Value)
+        if group == "" {
+            return models.Song{}, fmt.Errorf("%w: group cannot be cleared", models.ErrValidation)
+        }
+        artistId, err := s.artistRepo.ResolveArtist(group)
+        if err != nil {
+            return models.Song{}, err
+        }
+        changes.ArtistID = &artistId
+    }
+
+    if input.Song.Set {
+        if strings.TrimSpace(input.Song.Value) == "" {
+            return models.Song{}, fmt.Errorf("%w: song cannot be cleared", models.ErrValidation)
+        }
+        changes.SongName = &input.Song.Value
+    }
+
+    if input.ReleaseDate.Set {
+        releaseDate, err := models.ParseReleaseDate(input.ReleaseDate.Value)
+        if err != nil {
+            return models.Song{}, err
+        }
+        changes.ReleaseDate = &releaseDate
+    }
+
+    if input.Text.Set {
+        changes.Text = &input.Text.Value
+    }
+
+    if input.Link.Set {
+        changes.Link = &input.Link.Value
+    }
+
+    return s.repo.UpdateSongById(id, changes, audit)
+}
+
+func (s *SongServiceImpl) GetSongHistory(id, page, limit int) ([]models.SongRevision, int, error) {
+    return s.repo.GetSongHistory(id, page, limit)
+}
+
+// RevertSong brings the song back to its state at an earlier revision through
+// a regular update, which is recorded as a new revision. Tags, genres and
+// album placement are left as they are.
+func (s *SongServiceImpl) RevertSong(id, revision, version int, audit models.Audit) (models.Song, error) {
+    state, err := s.repo.GetSongRevision(id, revision)
+    if err != nil {
+        return models.Song{}, err
+    }
+    if state.Group == "" {
+        return models.Song{}, fmt.Errorf("%w: the artist of revision %d no longer exists", models.ErrConflict, revision)
+    }
+
+    return s.UpdateSongById(id, models.UpdateSongRequest{
+        Group:       state.Group,
+        Song:        state.SongName,
+        ReleaseDate: state.ReleaseDate,
+        Text:        state.Text,
+        Link:        state.Link,
+    }, version, audit)
 }
 
 func (s *SongServiceImpl) GetSongById(id int) (models.Song, error) {
@@ -91,7 +333,7 @@ func (s *SongServiceImpl) GetSongLyrics(songId int, page, limit int) ([]string,
             "songId": songId,
             "error":  err,
         }).Error("Failed to get song for lyrics")
-        return nil, 0, fmt.Errorf("song not found: %w", err)
+        return nil, 0, err
     }
 
     verses := strings.Split(song.Text, "\n\n")
@@ -117,7 +359,65 @@ func (s *SongServiceImpl) GetSongLyrics(songId int, page, limit int) ([]string,
     return verses[start:end], totalVerses, nil
 }
 
-func (s *SongServiceImpl) GetSongs(filter models.SongFilter, page, limit int) ([]models.Song, int, error) {
+func (s *SongServiceImpl) GetSongs(filter models.SongFilter, page models.SongPageRequest) (models.SongsResponse, error) {
+    if page.Page < 1 {
+        page.Page = 1
+    }
+    if page.Limit < 1 {
+        page.Limit = 10
+    } else if page.Limit > 100 {
+        page.Limit = 100
+    }
+
+    filter, err := normalizeSongFilter(filter)
+    if err != nil {
+        return models.SongsResponse{}, err
+    }
+    filter = withFuzzyThreshold(filter, s.fuzzyThreshold)
+
+    // One extra song is fetched to tell whether there is a next page.
+    songs, total, err := s.repo.GetSongsAfter(filter, page.After, (page.Page-1)*page.Limit, page.Limit+1, page.WithTotal)
+    if err != nil {
+        return models.SongsResponse{}, err
+    }
+
+    response := models.SongsResponse{
+        Data:  songs,
+        Total: total,
+        Limit: page.Limit,
+    }
+    if page.After == nil {
+        response.Page = page.Page
+    }
+
+    if len(songs) > page.Limit {
+        response.Data = songs[:page.Limit]
+        // Similarity scores depend on the search terms, so fuzzy results
+        // are only paged by number.
+        if filter.Match != "fuzzy" {
+            response.NextCursor = songCursor(response.Data[page.Limit-1], filter).Encode()
+        }
+    }
+
+    return response, nil
+}
+
+// ExportSongs passes every song matching filter to each, in the filter's
+// order.
+func (s *SongServiceImpl) ExportSongs(ctx context.Context, filter models.SongFilter, each func(models.Song) error) error {
+    filter, err := normalizeSongFilter(filter)
+    if err != nil {
+        return err
+    }
+    return s.repo.ExportSongs(ctx, withFuzzyThreshold(filter, s.fuzzyThreshold), each)
+}
+
+func (s *SongServiceImpl) SearchSongs(query string, page, limit int) ([]models.SongSearchHit, int, error) {
+    query = strings.TrimSpace(query)
+    if query == "" {
+        return nil, 0, fmt.Errorf("%w: search query must not be empty", models.ErrValidation)
+    }
+
     if page < 1 {
         page = 1
     }
@@ -126,6 +426,147 @@ func (s *SongServiceImpl) GetSongs(filter models.SongFilter, page, limit int) ([
     } else if limit > 100 {
         limit = 100
     }
-    
-    return s.repo.GetSongs(filter, page, limit)
-}
\ No newline at end of file
+
+    return s.repo.SearchSongs(query, page, limit)
+}
+
+// defaultDuplicateThreshold is the name similarity songs need to be reported
+// as duplicates when the request does not set its own threshold. It is
+// stricter than fuzzy search, which should rather find too much than too little.
+const defaultDuplicateThreshold = 0.6
+
+func (s *SongServiceImpl) GetSongDuplicates(threshold float64, page, limit int) ([]models.SongDuplicate, bool, error) {
+    if threshold < 0 || threshold > 1 {
+        return nil, false, fmt.Errorf("%w: threshold must be between 0 and 1", models.ErrValidation)
+    }
+    if threshold == 0 {
+        threshold = defaultDuplicateThreshold
+    }
+
+    return s.repo.GetSongDuplicates(threshold, page, limit)
+}
+
+const maxMergeSources = 100
+
+// MergeSongs folds the source songs into the target song, choosing each
+// field value by the requested strategy. The target keeps its group, name
+// and album placement; a target on no album takes the first source's one.
+func (s *SongServiceImpl) MergeSongs(targetId int, input models.MergeSongsRequest, audit models.Audit) (models.MergeSongsResponse, error) {
+    if len(input.SourceIDs) == 0 || len(input.SourceIDs) > maxMergeSources {
+        return models.MergeSongsResponse{}, fmt.Errorf("%w: between 1 and %d source songs can be merged at once", models.ErrValidation, maxMergeSources)
+    }
+
+    seen := map[int]bool{targetId: true}
+    for _, id := range input.SourceIDs {
+        if seen[id] {
+            return models.MergeSongsResponse{}, fmt.Errorf("%w: song %d is listed twice or is the target", models.ErrValidation, id)
+        }
+        seen[id] = true
+    }
+
+    strategy := input.Strategy
+    if !oneOf(strategy.ReleaseDate, "", "target", "earliest") {
+        return models.MergeSongsResponse{}, fmt.Errorf("%w: releaseDate strategy must be target or earliest", models.ErrValidation)
+    }
+    if !oneOf(strategy.Text, "", "target", "longest") || !oneOf(strategy.Link, "", "target", "longest") {
+        return models.MergeSongsResponse{}, fmt.Errorf("%w: text and link strategies must be target or longest", models.ErrValidation)
+    }
+
+    merge := func(target models.Song, sources []models.Song) models.Song {
+        merged := target
+        for _, source := range sources {
+            if strategy.ReleaseDate == "earliest" && source.ReleaseDate != "" &&
+                (merged.ReleaseDate == "" || source.ReleaseDate < merged.ReleaseDate) {
+                merged.ReleaseDate = source.ReleaseDate
+            }
+            if strategy.Text == "longest" && utf8.RuneCountInString(source.Text) > utf8.RuneCountInString(merged.Text) {
+                merged.Text = source.Text
+            }
+            if strategy.Link == "longest" && utf8.RuneCountInString(source.Link) > utf8.RuneCountInString(merged.Link) {
+                merged.Link = source.Link
+            }
+            if merged.AlbumID == nil && source.AlbumID != nil {
+                merged.AlbumID, merged.DiscNumber, merged.TrackNumber = source.AlbumID, source.DiscNumber, source.TrackNumber
+            }
+        }
+        return merged
+    }
+
+    song, err := s.repo.MergeSongs(targetId, input.SourceIDs, merge, input.DryRun, audit)
+    if err != nil {
+        return models.MergeSongsResponse{}, err
+    }
+
+    return models.MergeSongsResponse{Song: song, MergedIDs: input.SourceIDs, DryRun: input.DryRun}, nil
+}
+
+func oneOf(value string, allowed ...string) bool {
+    for _, candidate := range allowed {
+        if value == candidate {
+            return true
+        }
+    }
+    return false
+}
+
+// normalizeSongFilter brings user supplied filter values to the form the
+// repository compares against: lowercase labels and YYYY-MM-DD dates.
+func normalizeSongFilter(filter models.SongFilter) (models.SongFilter, error) {
+    var err error
+
+    filter.Genres = normalizeLabels(filter.Genres)
+    filter.Tags = normalizeLabels(filter.Tags)
+
+    if filter.ReleaseDate, err = models.ParseReleaseDate(filter.ReleaseDate); err != nil {
+        return filter, err
+    }
+    if filter.ReleasedFrom, err = models.ParseReleaseDate(filter.ReleasedFrom); err != nil {
+        return filter, err
+    }
+    if filter.ReleasedTo, err = models.ParseReleaseDate(filter.ReleasedTo); err != nil {
+        return filter, err
+    }
+
+    return filter, nil
+}
+
+// withFuzzyThreshold applies the configured similarity threshold to fuzzy
+// filters that do not set their own.
+func withFuzzyThreshold(filter models.SongFilter, threshold float64) models.SongFilter {
+    if filter.Match == "fuzzy" && filter.Threshold == 0 {
+        filter.Threshold = threshold
+    }
+    return filter
+}
+
+// songCursor returns the cursor pointing right after song in the order
+// requested by filter.
+func songCursor(song models.Song, filter models.SongFilter) models.SongCursor {
+    cursor := models.SongCursor{
+        SortBy:    filter.SortBy,
+        SortOrder: models.NormalizeSortOrder(filter.SortOrder),
+        ID:        song.ID,
+    }
+
+    var value string
+    switch filter.SortBy {
+    case "group":
+        value = song.Group
+    case "song":
+        value = song.SongName
+    case "releaseDate":
+        value = song.ReleaseDate
+    case "text":
+        value = song.Text
+    case "link":
+        value = song.Link
+    default:
+        return cursor
+    }
+    // Release date, text and link are NULL when empty; names never are empty.
+    if value != "" {
+        cursor.Value = &value
+    }
+
+    return cursor
+}
@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/AntonZatsepilin/music-library.git/internal/models"
+	"github.com/AntonZatsepilin/music-library.git/internal/repository"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	defaultSuggestLimit              = 10
+	maxSuggestLimit                  = 50
+	defaultSuggestionRefreshInterval = 5 * time.Minute
+)
+
+type SuggestServiceImpl struct {
+	repo repository.SuggestRepository
+}
+
+func NewSuggestService(repo repository.SuggestRepository) *SuggestServiceImpl {
+	return &SuggestServiceImpl{repo: repo}
+}
+
+func (s *SuggestServiceImpl) Suggest(kind, query string, limit int) ([]models.Suggestion, error) {
+	query = normalizeName(query)
+	if query == "" {
+		return nil, fmt.Errorf("%w: query must not be empty", models.ErrValidation)
+	}
+
+	if kind != "group" && kind != "song" {
+		return nil, fmt.Errorf("%w: type must be group or song", models.ErrValidation)
+	}
+
+	if limit < 1 {
+		limit = defaultSuggestLimit
+	} else if limit > maxSuggestLimit {
+		limit = maxSuggestLimit
+	}
+
+	return s.repo.Suggest(kind, strings.ToLower(query), limit)
+}
+
+// RunSuggestionRefresh recomputes the suggestion counts every interval until
+// ctx is done.
+func (s *SuggestServiceImpl) RunSuggestionRefresh(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = defaultSuggestionRefreshInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.repo.RefreshSuggestions(); err != nil {
+			logrus.WithError(err).Error("Suggestion refresh failed")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/AntonZatsepilin/music-library.git/internal/models"
+	"github.com/AntonZatsepilin/music-library.git/internal/repository"
+)
+
+const maxLabelLength = 100
+
+type TagServiceImpl struct {
+	repo repository.TagRepository
+}
+
+func NewTagService(repo repository.TagRepository) *TagServiceImpl {
+	return &TagServiceImpl{repo: repo}
+}
+
+// normalizeLabels lowercases tag and genre names, collapses whitespace and
+// drops empty and repeated entries, so "Rock", " rock" and "ROCK" are one tag.
+func normalizeLabels(names []string) []string {
+	seen := make(map[string]bool, len(names))
+	result := make([]string, 0, len(names))
+	for _, name := range names {
+		name = strings.ToLower(normalizeName(name))
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		result = append(result, name)
+	}
+	return result
+}
+
+func validateLabels(names []string) ([]string, error) {
+	names = normalizeLabels(names)
+	if len(names) == 0 {
+		return nil, fmt.Errorf("%w: at least one non-empty name is required", models.ErrValidation)
+	}
+	for _, name := range names {
+		if len(name) > maxLabelLength {
+			return nil, fmt.Errorf("%w: names must not be longer than %d characters", models.ErrValidation, maxLabelLength)
+		}
+	}
+	return names, nil
+}
+
+func (s *TagServiceImpl) AddSongTags(songId int, tags []string) error {
+	tags, err := validateLabels(tags)
+	if err != nil {
+		return err
+	}
+	return s.repo.AddSongTags(songId, tags)
+}
+
+func (s *TagServiceImpl) RemoveSongTag(songId int, tag string) error {
+	return s.repo.RemoveSongTag(songId, strings.ToLower(normalizeName(tag)))
+}
+
+func (s *TagServiceImpl) GetTagCounts() ([]models.TagCount, error) {
+	return s.repo.GetTagCounts()
+}
+
+func (s *TagServiceImpl) AddSongGenres(songId int, genres []string) error {
+	genres, err := validateLabels(genres)
+	if err != nil {
+		return err
+	}
+	return s.repo.AddSongGenres(songId, genres)
+}
+
+func (s *TagServiceImpl) RemoveSongGenre(songId int, genre string) error {
+	return s.repo.RemoveSongGenre(songId, strings.ToLower(normalizeName(genre)))
+}
+
+func (s *TagServiceImpl) GetGenreCounts() ([]models.TagCount, error) {
+	return s.repo.GetGenreCounts()
+}
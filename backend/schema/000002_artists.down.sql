@@ -0,0 +1,12 @@
+ALTER TABLE songs ADD COLUMN group_name VARCHAR(255);
+
+UPDATE songs SET group_name = artists.name
+FROM artists
+WHERE artists.id = songs.artist_id;
+
+ALTER TABLE songs ALTER COLUMN group_name SET NOT NULL;
+ALTER TABLE songs DROP COLUMN artist_id;
+
+DROP TABLE IF EXISTS artists;
+DROP FUNCTION IF EXISTS artist_alias_keys(TEXT[]);
+DROP FUNCTION IF EXISTS artist_name_key(TEXT);
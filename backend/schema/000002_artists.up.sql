@@ -0,0 +1,39 @@
+-- Artist names compare case-insensitively and ignoring repeated whitespace,
+-- the way the service normalizes them.
+CREATE OR REPLACE FUNCTION artist_name_key(name TEXT) RETURNS TEXT AS $$
+    SELECT lower(btrim(regexp_replace(name, '\s+', ' ', 'g')));
+$$ LANGUAGE sql IMMUTABLE;
+
+-- The keys of an artist's aliases, indexed so a name is looked up among them
+-- with artist_alias_keys(aliases) @> ARRAY[artist_name_key(name)].
+CREATE OR REPLACE FUNCTION artist_alias_keys(aliases TEXT[]) RETURNS TEXT[] AS $$
+    SELECT COALESCE(array_agg(artist_name_key(alias)), '{}') FROM unnest(aliases) AS alias;
+$$ LANGUAGE sql IMMUTABLE;
+
+CREATE TABLE IF NOT EXISTS artists (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    sort_name VARCHAR(255) NOT NULL DEFAULT '',
+    country VARCHAR(64) NOT NULL DEFAULT '',
+    formed_year INT,
+    aliases TEXT[] NOT NULL DEFAULT '{}'
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS artists_name_key ON artists (artist_name_key(name));
+CREATE INDEX IF NOT EXISTS artists_alias_keys_idx ON artists USING GIN (artist_alias_keys(aliases));
+
+INSERT INTO artists (name)
+SELECT DISTINCT ON (artist_name_key(group_name)) btrim(regexp_replace(group_name, '\s+', ' ', 'g'))
+FROM songs
+ORDER BY artist_name_key(group_name), group_name;
+
+ALTER TABLE songs ADD COLUMN artist_id INT REFERENCES artists (id) ON DELETE RESTRICT;
+
+UPDATE songs SET artist_id = artists.id
+FROM artists
+WHERE artist_name_key(songs.group_name) = artist_name_key(artists.name);
+
+ALTER TABLE songs ALTER COLUMN artist_id SET NOT NULL;
+ALTER TABLE songs DROP COLUMN group_name;
+
+CREATE INDEX IF NOT EXISTS songs_artist_id_idx ON songs (artist_id);
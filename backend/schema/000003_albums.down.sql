@@ -0,0 +1,7 @@
+ALTER TABLE songs
+    DROP CONSTRAINT IF EXISTS songs_album_position_check,
+    DROP COLUMN IF EXISTS track_number,
+    DROP COLUMN IF EXISTS disc_number,
+    DROP COLUMN IF EXISTS album_id;
+
+DROP TABLE IF EXISTS albums;
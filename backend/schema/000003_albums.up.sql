@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS albums (
+    id SERIAL PRIMARY KEY,
+    artist_id INT NOT NULL REFERENCES artists (id) ON DELETE RESTRICT,
+    title VARCHAR(255) NOT NULL,
+    release_year INT
+);
+
+CREATE INDEX IF NOT EXISTS albums_artist_id_idx ON albums (artist_id);
+
+ALTER TABLE songs
+    ADD COLUMN album_id INT REFERENCES albums (id),
+    ADD COLUMN disc_number INT,
+    ADD COLUMN track_number INT,
+    ADD CONSTRAINT songs_album_position_check CHECK (
+        (album_id IS NULL AND disc_number IS NULL AND track_number IS NULL)
+        OR (album_id IS NOT NULL AND disc_number >= 1 AND track_number >= 1)
+    );
+
+CREATE UNIQUE INDEX IF NOT EXISTS songs_album_position_key ON songs (album_id, disc_number, track_number);
@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS genres (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL UNIQUE
+);
+
+CREATE TABLE IF NOT EXISTS tags (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL UNIQUE
+);
+
+CREATE TABLE IF NOT EXISTS song_genres (
+    song_id INT NOT NULL REFERENCES songs (id) ON DELETE CASCADE,
+    genre_id INT NOT NULL REFERENCES genres (id) ON DELETE CASCADE,
+    PRIMARY KEY (song_id, genre_id)
+);
+
+CREATE INDEX IF NOT EXISTS song_genres_genre_id_idx ON song_genres (genre_id);
+
+CREATE TABLE IF NOT EXISTS song_tags (
+    song_id INT NOT NULL REFERENCES songs (id) ON DELETE CASCADE,
+    tag_id INT NOT NULL REFERENCES tags (id) ON DELETE CASCADE,
+    PRIMARY KEY (song_id, tag_id)
+);
+
+CREATE INDEX IF NOT EXISTS song_tags_tag_id_idx ON song_tags (tag_id);
@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS playlists (
+    id SERIAL PRIMARY KEY,
+    owner VARCHAR(255) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS playlists_owner_idx ON playlists (owner);
+
+CREATE TABLE IF NOT EXISTS playlist_entries (
+    id SERIAL PRIMARY KEY,
+    playlist_id INT NOT NULL REFERENCES playlists (id) ON DELETE CASCADE,
+    song_id INT NOT NULL REFERENCES songs (id) ON DELETE CASCADE,
+    position INT NOT NULL CHECK (position >= 1),
+    CONSTRAINT playlist_entries_position_key UNIQUE (playlist_id, position) DEFERRABLE INITIALLY DEFERRED
+);
+
+CREATE INDEX IF NOT EXISTS playlist_entries_song_id_idx ON playlist_entries (song_id);
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS smart_playlists (
+    id SERIAL PRIMARY KEY,
+    owner VARCHAR(255) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    rules JSONB NOT NULL DEFAULT '{}',
+    max_size INT NOT NULL CHECK (max_size >= 1),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS smart_playlists_owner_idx ON smart_playlists (owner);
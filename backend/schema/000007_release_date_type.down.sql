@@ -0,0 +1,11 @@
+DROP INDEX IF EXISTS songs_release_date_idx;
+
+ALTER TABLE songs ALTER COLUMN release_date TYPE VARCHAR(20) USING COALESCE(to_char(release_date, 'YYYY-MM-DD'), '');
+
+UPDATE songs SET release_date = u.release_date
+FROM songs_unparsed_release_dates u
+WHERE u.song_id = songs.id AND songs.release_date = '';
+
+DROP TABLE IF EXISTS songs_unparsed_release_dates;
+
+ALTER TABLE songs ALTER COLUMN release_date SET NOT NULL;
@@ -0,0 +1,61 @@
+-- parse_release_date reads the layouts models.ParseReleaseDate accepts and
+-- returns NULL for anything else, including impossible dates like 31.02.2006.
+CREATE OR REPLACE FUNCTION parse_release_date(value TEXT) RETURNS DATE AS $$
+DECLARE
+    v TEXT := btrim(value);
+    parts TEXT[];
+    months TEXT[] := ARRAY['january', 'february', 'march', 'april', 'may', 'june',
+        'july', 'august', 'september', 'october', 'november', 'december'];
+    abbreviations TEXT[] := ARRAY['jan', 'feb', 'mar', 'apr', 'may', 'jun',
+        'jul', 'aug', 'sep', 'oct', 'nov', 'dec'];
+BEGIN
+    IF v ~ '^\d{4}([-/])\d{1,2}\1\d{1,2}$' THEN
+        parts := regexp_split_to_array(v, '[-/]');
+        RETURN make_date(parts[1]::int, parts[2]::int, parts[3]::int);
+    ELSIF v ~ '^\d{1,2}([./-])\d{1,2}\1\d{4}$' THEN
+        parts := regexp_split_to_array(v, '[./-]');
+        RETURN make_date(parts[3]::int, parts[2]::int, parts[1]::int);
+    ELSIF v ~ '^\d{4}-\d{2}-\d{2}T' THEN
+        RETURN make_date(substr(v, 1, 4)::int, substr(v, 6, 2)::int, substr(v, 9, 2)::int);
+    ELSIF v ~ '^\d{1,2} [A-Za-z]+ \d{4}$' THEN
+        parts := regexp_match(v, '^(\d{1,2}) ([A-Za-z]+) (\d{4})$');
+        RETURN make_date(parts[3]::int,
+            COALESCE(array_position(months, lower(parts[2])), array_position(abbreviations, lower(parts[2]))),
+            parts[1]::int);
+    ELSIF v ~ '^[A-Za-z]+ \d{1,2}, \d{4}$' THEN
+        parts := regexp_match(v, '^([A-Za-z]+) (\d{1,2}), (\d{4})$');
+        RETURN make_date(parts[3]::int,
+            COALESCE(array_position(months, lower(parts[1])), array_position(abbreviations, lower(parts[1]))),
+            parts[2]::int);
+    ELSIF v ~ '^[A-Za-z]+ \d{4}$' THEN
+        parts := regexp_match(v, '^([A-Za-z]+) (\d{4})$');
+        RETURN make_date(parts[2]::int, array_position(months, lower(parts[1])), 1);
+    ELSIF v ~ '^\d{4}-\d{2}$' THEN
+        RETURN make_date(substr(v, 1, 4)::int, substr(v, 6, 2)::int, 1);
+    ELSIF v ~ '^\d{4}$' THEN
+        RETURN make_date(v::int, 1, 1);
+    END IF;
+    RETURN NULL;
+EXCEPTION WHEN others THEN
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql IMMUTABLE;
+
+-- Release dates that cannot be read are kept here rather than lost, so they
+-- can be fixed by hand and are put back by the down migration.
+CREATE TABLE IF NOT EXISTS songs_unparsed_release_dates (
+    song_id INT PRIMARY KEY REFERENCES songs (id) ON DELETE CASCADE,
+    release_date VARCHAR(20) NOT NULL
+);
+
+INSERT INTO songs_unparsed_release_dates (song_id, release_date)
+SELECT id, release_date FROM songs
+WHERE btrim(release_date) <> '' AND parse_release_date(release_date) IS NULL;
+
+ALTER TABLE songs ALTER COLUMN release_date DROP NOT NULL;
+
+ALTER TABLE songs ALTER COLUMN release_date TYPE DATE USING parse_release_date(release_date);
+
+DROP FUNCTION parse_release_date(TEXT);
+
+CREATE INDEX IF NOT EXISTS songs_release_date_idx ON songs (release_date);
@@ -0,0 +1,6 @@
+ALTER TABLE songs ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('english', coalesce(song_name, '')), 'A') ||
+    setweight(to_tsvector('english', coalesce(text, '')), 'B')
+) STORED;
+
+CREATE INDEX IF NOT EXISTS songs_search_vector_idx ON songs USING GIN (search_vector);
@@ -0,0 +1,4 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX IF NOT EXISTS artists_name_trgm_idx ON artists USING GIN (name gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS songs_song_name_trgm_idx ON songs USING GIN (song_name gin_trgm_ops);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS artists_song_count_idx;
+DROP INDEX IF EXISTS artists_name_prefix_idx;
+ALTER TABLE artists DROP COLUMN IF EXISTS song_count;
+
+DROP TABLE IF EXISTS song_titles;
@@ -0,0 +1,26 @@
+-- Distinct song titles and per-artist song counts for the suggestions.
+-- The counts are recomputed periodically by the server rather than by a
+-- trigger, which would serialize concurrent writes of an artist's songs on
+-- its row.
+
+CREATE TABLE IF NOT EXISTS song_titles (
+    title TEXT PRIMARY KEY,
+    song_count INT NOT NULL
+);
+
+INSERT INTO song_titles (title, song_count)
+SELECT song_name, COUNT(*) FROM songs GROUP BY song_name;
+
+ALTER TABLE artists ADD COLUMN song_count INT NOT NULL DEFAULT 0;
+
+UPDATE artists SET song_count = counts.song_count
+FROM (SELECT artist_id, COUNT(*) AS song_count FROM songs GROUP BY artist_id) counts
+WHERE counts.artist_id = artists.id;
+
+CREATE INDEX IF NOT EXISTS song_titles_prefix_idx ON song_titles (lower(title) text_pattern_ops);
+CREATE INDEX IF NOT EXISTS song_titles_trgm_idx ON song_titles USING GIN (title gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS artists_name_prefix_idx ON artists (lower(name) text_pattern_ops);
+
+-- Let the most used names be read in order when a prefix matches many names.
+CREATE INDEX IF NOT EXISTS song_titles_song_count_idx ON song_titles (song_count DESC, title) WHERE song_count > 0;
+CREATE INDEX IF NOT EXISTS artists_song_count_idx ON artists (song_count DESC, name) WHERE song_count > 0;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS songs_link_id_idx;
+DROP INDEX IF EXISTS songs_release_date_id_idx;
+DROP INDEX IF EXISTS songs_song_name_id_idx;
@@ -0,0 +1,3 @@
+CREATE INDEX IF NOT EXISTS songs_song_name_id_idx ON songs (song_name, id);
+CREATE INDEX IF NOT EXISTS songs_release_date_id_idx ON songs (release_date, id);
+CREATE INDEX IF NOT EXISTS songs_link_id_idx ON songs (link, id);
@@ -0,0 +1,4 @@
+DROP TRIGGER IF EXISTS songs_bump_version ON songs;
+DROP FUNCTION IF EXISTS songs_bump_version();
+
+ALTER TABLE songs DROP COLUMN IF EXISTS version;
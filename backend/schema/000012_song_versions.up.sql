@@ -0,0 +1,17 @@
+ALTER TABLE songs ADD COLUMN version INT NOT NULL DEFAULT 1;
+
+-- Every write to a song row moves it to the next version, so concurrent
+-- editors can detect that the song changed since they read it. A write that
+-- moves the version itself, like a merge taking over revisions, is kept.
+CREATE OR REPLACE FUNCTION songs_bump_version() RETURNS trigger AS $$
+BEGIN
+    IF NEW.version = OLD.version THEN
+        NEW.version := OLD.version + 1;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER songs_bump_version
+BEFORE UPDATE ON songs
+FOR EACH ROW EXECUTE FUNCTION songs_bump_version();
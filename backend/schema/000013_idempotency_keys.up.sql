@@ -0,0 +1,19 @@
+-- Responses to requests sent with an Idempotency-Key header, replayed when a
+-- client retries the same request. status_code is NULL while the first
+-- request with the key is still being processed. token identifies the
+-- request holding the key, which renews locked_at while it runs, so a retry
+-- can take over from one that never finished.
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    scope            TEXT NOT NULL,
+    key              TEXT NOT NULL,
+    request_hash     TEXT NOT NULL,
+    token            TEXT NOT NULL,
+    status_code      INT,
+    response_headers JSONB,
+    response_body    BYTEA,
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT now(),
+    locked_at        TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (scope, key)
+);
+
+CREATE INDEX IF NOT EXISTS idempotency_keys_created_at_idx ON idempotency_keys (created_at);
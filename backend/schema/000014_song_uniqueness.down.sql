@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS songs_name_key;
+ALTER TABLE songs DROP COLUMN IF EXISTS duplicate_of;
+DROP FUNCTION IF EXISTS song_name_key(TEXT);
@@ -0,0 +1,18 @@
+-- Song names compare case-insensitively and ignoring repeated whitespace.
+CREATE OR REPLACE FUNCTION song_name_key(name TEXT) RETURNS TEXT AS $$
+    SELECT lower(btrim(regexp_replace(name, '\s+', ' ', 'g')));
+$$ LANGUAGE sql IMMUTABLE;
+
+-- Songs that already duplicated an earlier song of the same artist point to
+-- it and are left out of the uniqueness check until they are merged or deleted.
+ALTER TABLE songs ADD COLUMN duplicate_of INT REFERENCES songs (id) ON DELETE SET NULL;
+
+UPDATE songs SET duplicate_of = d.original_id
+FROM (
+    SELECT id, min(id) OVER (PARTITION BY artist_id, song_name_key(song_name)) AS original_id
+    FROM songs
+) d
+WHERE d.id = songs.id AND d.original_id <> songs.id;
+
+CREATE UNIQUE INDEX IF NOT EXISTS songs_name_key ON songs (artist_id, song_name_key(song_name))
+WHERE duplicate_of IS NULL;
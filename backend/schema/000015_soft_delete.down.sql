@@ -0,0 +1,27 @@
+-- Songs in the trash come back; those whose album position was taken by
+-- another song are taken off their albums.
+DROP INDEX IF EXISTS songs_album_position_key;
+UPDATE songs SET album_id = NULL, disc_number = NULL, track_number = NULL
+FROM (
+    SELECT id, row_number() OVER (
+        PARTITION BY album_id, disc_number, track_number ORDER BY deleted_at IS NOT NULL, id
+    ) AS n
+    FROM songs
+    WHERE album_id IS NOT NULL
+) p
+WHERE p.id = songs.id AND p.n > 1;
+CREATE UNIQUE INDEX songs_album_position_key ON songs (album_id, disc_number, track_number);
+
+DROP INDEX IF EXISTS songs_name_key;
+UPDATE songs SET duplicate_of = d.original_id
+FROM (
+    SELECT id, min(id) OVER (PARTITION BY artist_id, song_name_key(song_name)) AS original_id
+    FROM songs
+    WHERE duplicate_of IS NULL
+) d
+WHERE d.id = songs.id AND d.original_id <> songs.id;
+CREATE UNIQUE INDEX songs_name_key ON songs (artist_id, song_name_key(song_name))
+WHERE duplicate_of IS NULL;
+
+DROP INDEX IF EXISTS songs_deleted_at_idx;
+ALTER TABLE songs DROP COLUMN IF EXISTS deleted_at;
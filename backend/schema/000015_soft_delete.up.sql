@@ -0,0 +1,14 @@
+ALTER TABLE songs ADD COLUMN deleted_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS songs_deleted_at_idx ON songs (deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- Songs in the trash may be recreated; restoring one then is a conflict.
+DROP INDEX IF EXISTS songs_name_key;
+CREATE UNIQUE INDEX songs_name_key ON songs (artist_id, song_name_key(song_name))
+WHERE duplicate_of IS NULL AND deleted_at IS NULL;
+
+-- Songs in the trash give up their album position too, so another song can
+-- take it; restoring the song then is a conflict.
+DROP INDEX IF EXISTS songs_album_position_key;
+CREATE UNIQUE INDEX songs_album_position_key ON songs (album_id, disc_number, track_number)
+WHERE deleted_at IS NULL;
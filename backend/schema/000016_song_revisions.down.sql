@@ -0,0 +1,4 @@
+DROP TRIGGER IF EXISTS songs_record_revision ON songs;
+DROP FUNCTION IF EXISTS songs_record_revision();
+
+DROP TABLE IF EXISTS song_revisions;
@@ -0,0 +1,83 @@
+-- Every change to a song is kept as a revision. song_id has no foreign key,
+-- so the history outlives the song. revision is the song version after the
+-- change; state holds the tracked columns after the change, or before it for
+-- a removal.
+CREATE TABLE IF NOT EXISTS song_revisions (
+    id         BIGSERIAL PRIMARY KEY,
+    song_id    INT NOT NULL,
+    revision   INT NOT NULL,
+    operation  TEXT NOT NULL,
+    changes    JSONB NOT NULL,
+    state      JSONB NOT NULL,
+    actor      TEXT,
+    request_id TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CONSTRAINT song_revisions_revision_key UNIQUE (song_id, revision)
+);
+
+-- The application names the actor and request of a write with
+-- set_config('app.actor', ..., true) and set_config('app.request_id', ..., true).
+CREATE OR REPLACE FUNCTION songs_record_revision() RETURNS trigger AS $$
+DECLARE
+    tracked     TEXT[] := ARRAY['artist_id', 'song_name', 'release_date', 'text', 'link',
+                                'album_id', 'disc_number', 'track_number', 'deleted_at'];
+    old_row     JSONB := '{}';
+    new_row     JSONB := '{}';
+    row_state   JSONB;
+    revision    INT;
+    changes     JSONB := '{}';
+    column_name TEXT;
+    operation   TEXT;
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        old_row := to_jsonb(OLD);
+        row_state := old_row;
+        revision := OLD.version + 1;
+    ELSE
+        IF TG_OP = 'UPDATE' THEN
+            old_row := to_jsonb(OLD);
+        END IF;
+        new_row := to_jsonb(NEW);
+        row_state := new_row;
+        revision := NEW.version;
+    END IF;
+
+    FOREACH column_name IN ARRAY tracked LOOP
+        IF COALESCE(old_row -> column_name, 'null') IS DISTINCT FROM COALESCE(new_row -> column_name, 'null') THEN
+            changes := changes || jsonb_build_object(column_name,
+                jsonb_build_object('old', old_row -> column_name, 'new', new_row -> column_name));
+        END IF;
+    END LOOP;
+
+    -- Writes that only bump the version, like tag changes, are not revisions.
+    IF changes = '{}' THEN
+        RETURN NULL;
+    END IF;
+
+    operation := CASE
+        WHEN TG_OP = 'INSERT' THEN 'create'
+        WHEN TG_OP = 'DELETE' AND old_row ->> 'deleted_at' IS NULL THEN 'delete'
+        WHEN TG_OP = 'DELETE' THEN 'purge'
+        WHEN old_row ->> 'deleted_at' IS NULL AND new_row ->> 'deleted_at' IS NOT NULL THEN 'delete'
+        WHEN old_row ->> 'deleted_at' IS NOT NULL AND new_row ->> 'deleted_at' IS NULL THEN 'restore'
+        ELSE 'update'
+    END;
+
+    INSERT INTO song_revisions (song_id, revision, operation, changes, state, actor, request_id)
+    VALUES (
+        CAST(row_state ->> 'id' AS INT),
+        revision,
+        operation,
+        changes,
+        (SELECT jsonb_object_agg(c, row_state -> c) FROM unnest(tracked) AS c),
+        NULLIF(current_setting('app.actor', true), ''),
+        NULLIF(current_setting('app.request_id', true), '')
+    );
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER songs_record_revision
+AFTER INSERT OR UPDATE OR DELETE ON songs
+FOR EACH ROW EXECUTE FUNCTION songs_record_revision();
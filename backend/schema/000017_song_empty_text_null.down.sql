@@ -0,0 +1,8 @@
+ALTER TABLE songs DISABLE TRIGGER songs_bump_version;
+ALTER TABLE songs DISABLE TRIGGER songs_record_revision;
+
+UPDATE songs SET text = COALESCE(text, ''), link = COALESCE(link, '')
+WHERE text IS NULL OR link IS NULL;
+
+ALTER TABLE songs ENABLE TRIGGER songs_record_revision;
+ALTER TABLE songs ENABLE TRIGGER songs_bump_version;
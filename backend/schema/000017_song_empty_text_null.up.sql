@@ -0,0 +1,10 @@
+-- Empty lyrics and links are stored as NULL, like an empty release date. The
+-- rows keep their version and get no revision, as nothing visible changes.
+ALTER TABLE songs DISABLE TRIGGER songs_bump_version;
+ALTER TABLE songs DISABLE TRIGGER songs_record_revision;
+
+UPDATE songs SET text = NULLIF(text, ''), link = NULLIF(link, '')
+WHERE text = '' OR link = '';
+
+ALTER TABLE songs ENABLE TRIGGER songs_record_revision;
+ALTER TABLE songs ENABLE TRIGGER songs_bump_version;